@@ -0,0 +1,61 @@
+package tracer
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultAdaptiveInterval = 10 * time.Second
+	minAdaptiveRatio        = 0.0001
+)
+
+// adaptiveRatio steers a DynamicSampler towards target sampled root spans per
+// second. It is the fetch function of a sampling poller, so next is only
+// called from one goroutine. Only the decisions of the DynamicSampler are
+// counted: local root spans it sampled, not their children nor roots kept by
+// route, endpoint or internal span overrides, which the ratio cannot steer.
+type adaptiveRatio struct {
+	target  float64
+	max     float64
+	sampler *DynamicSampler
+
+	spans    atomic.Int64
+	last     time.Time
+	observed float64 // smoothed spans per second
+}
+
+func newAdaptiveRatio(sampler *DynamicSampler, target, ceiling float64) *adaptiveRatio {
+	a := &adaptiveRatio{target: target, max: ceiling, sampler: sampler, last: time.Now()}
+	sampler.sampled.Store(&a.spans)
+	return a
+}
+
+// next returns the ratio for the coming interval. The observed rate is
+// smoothed over intervals, and each step only goes halfway to the ratio
+// that would hit the target, at most halving or doubling it, so the ratio
+// settles instead of oscillating.
+func (a *adaptiveRatio) next(context.Context) (float64, error) {
+	now := time.Now()
+	elapsed := now.Sub(a.last).Seconds()
+	a.last = now
+	ratio := a.sampler.Ratio()
+	if elapsed <= 0 {
+		return ratio, nil
+	}
+	rate := float64(a.spans.Swap(0)) / elapsed
+	if a.observed == 0 {
+		a.observed = rate
+	} else {
+		a.observed = (rate + a.observed) / 2
+	}
+
+	want := a.max
+	if a.observed > 0 {
+		want = ratio * a.target / a.observed
+	}
+	next := ratio + (want-ratio)/2
+	next = min(max(next, ratio/2), ratio*2)
+	return min(max(next, minAdaptiveRatio), a.max), nil
+}
@@ -0,0 +1,131 @@
+package tracer
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SpanAggregator receives finished sampled server spans, e.g. to compute
+// latency SLOs in process without a tracing backend.
+type SpanAggregator interface {
+	Observe(route string, d time.Duration, status int)
+}
+
+// aggregatorProcessor forwards server spans to a SpanAggregator.
+type aggregatorProcessor struct {
+	aggregator SpanAggregator
+}
+
+func (aggregatorProcessor) OnStart(context.Context, sdktrace.ReadWriteSpan) {}
+
+func (p aggregatorProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	if s.SpanKind() != trace.SpanKindServer {
+		return
+	}
+	route, status := s.Name(), 0
+	for _, attr := range s.Attributes() {
+		switch attr.Key {
+		case semconv.HTTPRouteKey:
+			route = attr.Value.AsString()
+		case semconv.HTTPResponseStatusCodeKey:
+			status = int(attr.Value.AsInt64())
+		}
+	}
+	p.aggregator.Observe(route, s.EndTime().Sub(s.StartTime()), status)
+}
+
+func (aggregatorProcessor) Shutdown(context.Context) error   { return nil }
+func (aggregatorProcessor) ForceFlush(context.Context) error { return nil }
+
+const (
+	defaultPercentileSamples = 1024
+	// maxPercentileRoutes bounds the routes tracked separately, e.g. when
+	// raw paths end up as span names. Later routes share OtherRoute.
+	maxPercentileRoutes = 256
+)
+
+// OtherRoute collects the durations of the routes observed after the
+// PercentileAggregator already tracks its maximum number of routes.
+const OtherRoute = "other"
+
+// PercentileAggregator keeps the most recent durations per route and answers
+// latency percentile queries over them.
+type PercentileAggregator struct {
+	mu      sync.Mutex
+	size    int
+	samples map[string]*durationRing
+}
+
+type durationRing struct {
+	values []time.Duration
+	next   int
+}
+
+// NewPercentileAggregator keeps up to size samples per route (default 1024
+// when size <= 0) for up to 256 routes, with the rest under OtherRoute.
+func NewPercentileAggregator(size int) *PercentileAggregator {
+	if size <= 0 {
+		size = defaultPercentileSamples
+	}
+	return &PercentileAggregator{size: size, samples: map[string]*durationRing{}}
+}
+
+func (a *PercentileAggregator) Observe(route string, d time.Duration, _ int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	ring, ok := a.samples[route]
+	if !ok {
+		if len(a.samples) >= maxPercentileRoutes {
+			route = OtherRoute
+			ring = a.samples[route]
+		}
+		if ring == nil {
+			// The ring grows as samples arrive, so rare routes stay small.
+			ring = &durationRing{}
+			a.samples[route] = ring
+		}
+	}
+	if len(ring.values) < a.size {
+		ring.values = append(ring.values, d)
+		return
+	}
+	ring.values[ring.next] = d
+	ring.next = (ring.next + 1) % a.size
+}
+
+// Percentile returns the p-th percentile (0-100) of the recorded durations
+// for route, or 0 when nothing was recorded.
+func (a *PercentileAggregator) Percentile(route string, p float64) time.Duration {
+	a.mu.Lock()
+	ring, ok := a.samples[route]
+	var values []time.Duration
+	if ok {
+		values = append(values, ring.values...)
+	}
+	a.mu.Unlock()
+
+	if len(values) == 0 {
+		return 0
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	idx := int(clampRate(p/100) * float64(len(values)-1))
+	return values[idx]
+}
+
+// Routes returns the routes observed so far.
+func (a *PercentileAggregator) Routes() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	routes := make([]string, 0, len(a.samples))
+	for route := range a.samples {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+	return routes
+}
@@ -0,0 +1,24 @@
+package tracer
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPercentileAggregatorCapsRoutes(t *testing.T) {
+	a := NewPercentileAggregator(0)
+	for i := 0; i < maxPercentileRoutes+10; i++ {
+		a.Observe(fmt.Sprintf("/users/%d", i), time.Duration(i)*time.Millisecond, 200)
+	}
+
+	if got := len(a.Routes()); got != maxPercentileRoutes+1 {
+		t.Errorf("tracking %d routes, want %d", got, maxPercentileRoutes+1)
+	}
+	if got := a.Percentile(OtherRoute, 100); got != time.Duration(maxPercentileRoutes+9)*time.Millisecond {
+		t.Errorf("%s p100 = %v, want the overflow durations", OtherRoute, got)
+	}
+	if got := cap(a.samples["/users/0"].values); got >= defaultPercentileSamples {
+		t.Errorf("ring capacity %d, want it to grow lazily", got)
+	}
+}
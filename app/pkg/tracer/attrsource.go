@@ -0,0 +1,122 @@
+package tracer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	attributeSourceContext = "context"
+	attributeSourceHeader  = "header"
+	attributeSourceBaggage = "baggage"
+)
+
+var contextKeys sync.Map // name -> context key
+
+// RegisterContextKey names a context key for "context:<name>" sources of
+// Config.AttributeSources, since context keys are usually unexported typed
+// values rather than strings.
+func RegisterContextKey(name string, key any) {
+	contextKeys.Store(name, key)
+}
+
+type attributeSource struct {
+	key  attribute.Key
+	kind string
+	name string
+}
+
+// parseAttributeSources parses Config.AttributeSources, skipping and
+// reporting invalid entries. Sources are sorted by attribute key.
+func parseAttributeSources(sources map[string]string) ([]attributeSource, []error) {
+	var parsed []attributeSource
+	var errs []error
+	for key, source := range sources {
+		kind, name, ok := strings.Cut(source, ":")
+		switch {
+		case !ok || name == "":
+			errs = append(errs, fmt.Errorf("attribute source %q for %s: want <kind>:<name>", source, key))
+		case kind != attributeSourceContext && kind != attributeSourceHeader && kind != attributeSourceBaggage:
+			errs = append(errs, fmt.Errorf("attribute source %q for %s: unknown kind %q", source, key, kind))
+		default:
+			parsed = append(parsed, attributeSource{key: attribute.Key(key), kind: kind, name: name})
+		}
+	}
+	sort.Slice(parsed, func(i, j int) bool { return parsed[i].key < parsed[j].key })
+	return parsed, errs
+}
+
+// attributeSourceProcessor sets the context and baggage sourced attributes
+// on starting spans, from their parent context.
+type attributeSourceProcessor struct {
+	sources []attributeSource
+}
+
+func (p attributeSourceProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	for _, source := range p.sources {
+		switch source.kind {
+		case attributeSourceContext:
+			key, ok := contextKeys.Load(source.name)
+			if !ok {
+				key = source.name
+			}
+			if v := parent.Value(key); v != nil {
+				s.SetAttributes(contextValueAttribute(source.key, v))
+			}
+		case attributeSourceBaggage:
+			if member := baggage.FromContext(parent).Member(source.name); member.Key() != "" {
+				s.SetAttributes(source.key.String(member.Value()))
+			}
+		}
+	}
+}
+
+func (attributeSourceProcessor) OnEnd(sdktrace.ReadOnlySpan)      {}
+func (attributeSourceProcessor) Shutdown(context.Context) error   { return nil }
+func (attributeSourceProcessor) ForceFlush(context.Context) error { return nil }
+
+func contextValueAttribute(key attribute.Key, v any) attribute.KeyValue {
+	switch v := v.(type) {
+	case string:
+		return key.String(v)
+	case bool:
+		return key.Bool(v)
+	case int:
+		return key.Int(v)
+	case int64:
+		return key.Int64(v)
+	case float64:
+		return key.Float64(v)
+	case fmt.Stringer:
+		return key.String(v.String())
+	}
+	return key.String(fmt.Sprint(v))
+}
+
+// attributeSourceMiddleware sets the header sourced attributes on the
+// request span.
+func attributeSourceMiddleware(sources []attributeSource) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		span := trace.SpanFromContext(c.Request.Context())
+		if span.IsRecording() {
+			for _, source := range sources {
+				if source.kind != attributeSourceHeader {
+					continue
+				}
+				if value := c.GetHeader(source.name); value != "" {
+					span.SetAttributes(source.key.String(value))
+				}
+			}
+		}
+		c.Next()
+	}
+}
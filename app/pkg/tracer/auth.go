@@ -0,0 +1,62 @@
+package tracer
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// AuthInfo describes how a request was authenticated, as set by the auth
+// middleware. It must never hold credentials.
+type AuthInfo struct {
+	Scheme   string
+	ClientID string
+}
+
+// DefaultAuthSchemes are the auth.scheme values recorded when
+// Config.AuthSchemes is empty.
+var DefaultAuthSchemes = []string{"bearer", "apikey", "mtls", "basic"}
+
+// safeClientID matches client IDs that can be recorded: short identifiers
+// without whitespace. JWTs are rejected separately.
+var safeClientID = regexp.MustCompile(`^[A-Za-z0-9._:@/-]{1,128}$`)
+
+// authMiddleware records auth.scheme and auth.client_id from the AuthInfo
+// returned by extract. Schemes outside allowlist are recorded as "other",
+// and client IDs that could be credentials are dropped.
+func authMiddleware(extract func(*gin.Context) AuthInfo, allowlist []string) gin.HandlerFunc {
+	if len(allowlist) == 0 {
+		allowlist = DefaultAuthSchemes
+	}
+	schemes := make(map[string]bool, len(allowlist))
+	for _, scheme := range allowlist {
+		schemes[strings.ToLower(scheme)] = true
+	}
+	return func(c *gin.Context) {
+		span := trace.SpanFromContext(c.Request.Context())
+		if span.IsRecording() {
+			info := extract(c)
+			if scheme := strings.ToLower(strings.TrimSpace(info.Scheme)); scheme != "" {
+				if !schemes[scheme] {
+					scheme = "other"
+				}
+				span.SetAttributes(attribute.String("auth.scheme", scheme))
+			}
+			if recordableClientID(info.ClientID) {
+				span.SetAttributes(attribute.String("auth.client_id", info.ClientID))
+			}
+		}
+		c.Next()
+	}
+}
+
+func recordableClientID(id string) bool {
+	if !safeClientID.MatchString(id) {
+		return false
+	}
+	// Three dot separated segments starting with "eyJ" is a JWT.
+	return !(strings.HasPrefix(id, "eyJ") && strings.Count(id, ".") == 2)
+}
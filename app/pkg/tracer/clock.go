@@ -0,0 +1,63 @@
+package tracer
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/embedded"
+)
+
+// Clock is the time source used for span timing. It is meant to be replaced
+// by a fake clock in tests so duration based behaviour is deterministic.
+type Clock interface {
+	Now() time.Time
+}
+
+// clockTracerProvider stamps span start, event and end timestamps from clock
+// instead of the wall clock used by the SDK.
+type clockTracerProvider struct {
+	embedded.TracerProvider
+
+	provider trace.TracerProvider
+	clock    Clock
+}
+
+func newClockTracerProvider(provider trace.TracerProvider, clock Clock) trace.TracerProvider {
+	return &clockTracerProvider{provider: provider, clock: clock}
+}
+
+func (p *clockTracerProvider) Tracer(name string, opts ...trace.TracerOption) trace.Tracer {
+	return &clockTracer{tracer: p.provider.Tracer(name, opts...), clock: p.clock}
+}
+
+type clockTracer struct {
+	embedded.Tracer
+
+	tracer trace.Tracer
+	clock  Clock
+}
+
+func (t *clockTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	// Explicit timestamps passed by the caller come later and still win.
+	opts = append([]trace.SpanStartOption{trace.WithTimestamp(t.clock.Now())}, opts...)
+	ctx, span := t.tracer.Start(ctx, name, opts...)
+	wrapped := &clockSpan{Span: span, clock: t.clock}
+	return trace.ContextWithSpan(ctx, wrapped), wrapped
+}
+
+type clockSpan struct {
+	trace.Span
+
+	clock Clock
+}
+
+func (s *clockSpan) AddEvent(name string, opts ...trace.EventOption) {
+	opts = append([]trace.EventOption{trace.WithTimestamp(s.clock.Now())}, opts...)
+	s.Span.AddEvent(name, opts...)
+}
+
+func (s *clockSpan) End(opts ...trace.SpanEndOption) {
+	opts = append([]trace.SpanEndOption{trace.WithTimestamp(s.clock.Now())}, opts...)
+	s.Span.End(opts...)
+}
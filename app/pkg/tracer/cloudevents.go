@@ -0,0 +1,142 @@
+package tracer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+const (
+	cloudEventsSpecVersion = "1.0"
+	cloudEventsSpanType    = "io.opentelemetry.span"
+	cloudEventsBatchType   = "application/cloudevents-batch+json"
+)
+
+// newCloudEventsExporter posts spans to config.CloudEventsSink as a
+// CloudEvents JSON batch, one event per span.
+func newCloudEventsExporter(config Config) (sdktrace.SpanExporter, error) {
+	if config.CloudEventsSink == "" {
+		return nil, errors.New("cloudevents sink not configured")
+	}
+	client := &http.Client{}
+	if config.ProxyURL != "" {
+		proxy, err := url.Parse(config.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = http.ProxyURL(proxy)
+		client.Transport = transport
+	}
+	loggerOrDefault(config.Logger).Printf("Posting traces as CloudEvents to %s", config.CloudEventsSink)
+	return &cloudEventsExporter{sink: config.CloudEventsSink, client: client}, nil
+}
+
+type cloudEventsExporter struct {
+	sink   string
+	client *http.Client
+}
+
+type cloudEvent struct {
+	SpecVersion     string         `json:"specversion"`
+	ID              string         `json:"id"`
+	Source          string         `json:"source"`
+	Type            string         `json:"type"`
+	Time            time.Time      `json:"time"`
+	DataContentType string         `json:"datacontenttype"`
+	Data            cloudEventSpan `json:"data"`
+}
+
+type cloudEventSpan struct {
+	TraceID           string         `json:"trace_id"`
+	SpanID            string         `json:"span_id"`
+	ParentSpanID      string         `json:"parent_span_id,omitempty"`
+	Name              string         `json:"name"`
+	Kind              string         `json:"kind"`
+	StartTime         time.Time      `json:"start_time"`
+	EndTime           time.Time      `json:"end_time"`
+	DurationMS        float64        `json:"duration_ms"`
+	StatusCode        string         `json:"status_code"`
+	StatusDescription string         `json:"status_description,omitempty"`
+	Attributes        map[string]any `json:"attributes,omitempty"`
+}
+
+func (e *cloudEventsExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	events := make([]cloudEvent, 0, len(spans))
+	for _, s := range spans {
+		events = append(events, spanCloudEvent(s))
+	}
+	body, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.sink, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", cloudEventsBatchType)
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post cloudevents: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("post cloudevents: %s", resp.Status)
+	}
+	return nil
+}
+
+func (e *cloudEventsExporter) Shutdown(context.Context) error {
+	e.client.CloseIdleConnections()
+	return nil
+}
+
+func spanCloudEvent(s sdktrace.ReadOnlySpan) cloudEvent {
+	sc := s.SpanContext()
+	data := cloudEventSpan{
+		TraceID:           sc.TraceID().String(),
+		SpanID:            sc.SpanID().String(),
+		Name:              s.Name(),
+		Kind:              s.SpanKind().String(),
+		StartTime:         s.StartTime(),
+		EndTime:           s.EndTime(),
+		DurationMS:        float64(s.EndTime().Sub(s.StartTime())) / float64(time.Millisecond),
+		StatusCode:        s.Status().Code.String(),
+		StatusDescription: s.Status().Description,
+	}
+	if parent := s.Parent(); parent.IsValid() {
+		data.ParentSpanID = parent.SpanID().String()
+	}
+	if attrs := s.Attributes(); len(attrs) > 0 {
+		data.Attributes = make(map[string]any, len(attrs))
+		for _, attr := range attrs {
+			data.Attributes[string(attr.Key)] = attr.Value.AsInterface()
+		}
+	}
+
+	source := "unknown_service"
+	if res := s.Resource(); res != nil {
+		if name, ok := res.Set().Value(semconv.ServiceNameKey); ok {
+			source = name.AsString()
+		}
+	}
+	return cloudEvent{
+		SpecVersion:     cloudEventsSpecVersion,
+		ID:              data.TraceID + "-" + data.SpanID,
+		Source:          source,
+		Type:            cloudEventsSpanType,
+		Time:            s.EndTime(),
+		DataContentType: "application/json",
+		Data:            data,
+	}
+}
@@ -0,0 +1,117 @@
+package tracer
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// coalesceProcessor merges consecutive identical sibling spans ending within
+// window of each other into the first one. See Config.CoalesceWindow.
+type coalesceProcessor struct {
+	sdktrace.SpanProcessor
+
+	window time.Duration
+
+	mu      sync.Mutex
+	pending map[trace.SpanID]*coalescedSpan // last ended span per parent
+}
+
+func newCoalesceProcessor(next sdktrace.SpanProcessor, window time.Duration) *coalesceProcessor {
+	return &coalesceProcessor{SpanProcessor: next, window: window, pending: map[trace.SpanID]*coalescedSpan{}}
+}
+
+func (p *coalesceProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	if isLocalRoot(s.Parent()) {
+		p.SpanProcessor.OnEnd(s)
+		return
+	}
+	parent := s.Parent().SpanID()
+
+	p.mu.Lock()
+	prev := p.pending[parent]
+	if prev != nil && prev.matches(s) && s.StartTime().Sub(prev.end) <= p.window {
+		prev.count++
+		prev.end = s.EndTime()
+		prev.timer.Reset(p.window)
+		p.mu.Unlock()
+		return
+	}
+	c := &coalescedSpan{ReadOnlySpan: s, end: s.EndTime(), count: 1}
+	c.timer = time.AfterFunc(p.window, func() { p.flush(parent, c) })
+	p.pending[parent] = c
+	p.mu.Unlock()
+
+	if prev != nil {
+		prev.timer.Stop()
+		p.SpanProcessor.OnEnd(prev)
+	}
+}
+
+// flush exports c unless it was already replaced or flushed.
+func (p *coalesceProcessor) flush(parent trace.SpanID, c *coalescedSpan) {
+	p.mu.Lock()
+	if p.pending[parent] != c {
+		p.mu.Unlock()
+		return
+	}
+	delete(p.pending, parent)
+	p.mu.Unlock()
+	p.SpanProcessor.OnEnd(c)
+}
+
+func (p *coalesceProcessor) flushAll() {
+	p.mu.Lock()
+	pending := p.pending
+	p.pending = map[trace.SpanID]*coalescedSpan{}
+	p.mu.Unlock()
+
+	for _, c := range pending {
+		c.timer.Stop()
+		p.SpanProcessor.OnEnd(c)
+	}
+}
+
+func (p *coalesceProcessor) ForceFlush(ctx context.Context) error {
+	p.flushAll()
+	return p.SpanProcessor.ForceFlush(ctx)
+}
+
+func (p *coalesceProcessor) Shutdown(ctx context.Context) error {
+	p.flushAll()
+	return p.SpanProcessor.Shutdown(ctx)
+}
+
+// coalescedSpan is the first of a run of identical spans, ending when the
+// last one ended.
+type coalescedSpan struct {
+	sdktrace.ReadOnlySpan
+
+	end   time.Time
+	count int
+	timer *time.Timer
+}
+
+func (c *coalescedSpan) matches(s sdktrace.ReadOnlySpan) bool {
+	if s.Name() != c.Name() || s.SpanKind() != c.SpanKind() || s.Status() != c.Status() {
+		return false
+	}
+	a, b := attribute.NewSet(c.ReadOnlySpan.Attributes()...), attribute.NewSet(s.Attributes()...)
+	return a.Equals(&b)
+}
+
+func (c *coalescedSpan) EndTime() time.Time {
+	return c.end
+}
+
+func (c *coalescedSpan) Attributes() []attribute.KeyValue {
+	attrs := c.ReadOnlySpan.Attributes()
+	if c.count == 1 {
+		return attrs
+	}
+	return append(attrs[:len(attrs):len(attrs)], attribute.Int("span.repeat_count", c.count))
+}
@@ -0,0 +1,109 @@
+package tracer
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// dsnTools maps DSN schemes to tracing tools.
+var dsnTools = map[string]TracingTool{
+	"otlp":      ToolOTLP,
+	"otlp+grpc": ToolOTLPGRPC,
+	"otlp+http": ToolOTLPHTTP,
+	"signoz":    ToolSigNoz,
+	"jaeger":    ToolJaeger,
+	"gcp":       ToolGCP,
+	"stdout":    ToolStdout,
+	"file":      ToolFile,
+}
+
+// ConfigFromDSN builds a Config from a single DSN such as
+//
+//	otlp://token@collector:4317?sampling=0.1&compression=gzip
+//
+// The scheme selects the tool (otlp, otlp+grpc, otlp+http, signoz, jaeger,
+// gcp, stdout, file) and the host the endpoint. For gcp the host is the
+// project ID, for file the path is the trace file. The user info is sent as
+// a bearer token, or as the ingestion key for signoz; a password is rejected
+// rather than silently dropped. Supported query
+// parameters: sampling (0 to 1), compression (gzip or none), insecure
+// (true/false), region (signoz cloud) and header.<name>=<value>.
+func ConfigFromDSN(dsn string) (Config, error) {
+	var config Config
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return config, fmt.Errorf("invalid tracing DSN: %w", err)
+	}
+	tool, ok := dsnTools[strings.ToLower(u.Scheme)]
+	if !ok {
+		return config, fmt.Errorf("invalid tracing DSN: unsupported scheme %q", u.Scheme)
+	}
+	config.TracingTool = string(tool)
+
+	switch tool {
+	case ToolGCP:
+		if u.Host == "" {
+			return config, errors.New("invalid tracing DSN: gcp needs the project as host")
+		}
+		config.GoogleCloudProject = u.Host
+	case ToolFile:
+		config.TraceFilePath = u.Host + u.Path
+	case ToolJaeger:
+		if u.Host != "" {
+			config.JaegerEndpoint = (&url.URL{Scheme: "http", Host: u.Host, Path: u.Path}).String()
+		}
+	default:
+		config.OTLPEndpoint = u.Host
+	}
+
+	if _, hasPassword := u.User.Password(); hasPassword {
+		return config, errors.New("invalid tracing DSN: user info is a token, passwords are not supported")
+	}
+	if token := u.User.Username(); token != "" {
+		if tool == ToolSigNoz {
+			config.SigNozCloud = true
+			config.SigNozIngestionKey = token
+		} else {
+			config.OTLPHeaders = map[string]string{"Authorization": "Bearer " + token}
+		}
+	}
+
+	for key, values := range u.Query() {
+		value := values[len(values)-1]
+		switch {
+		case key == "sampling":
+			rate, err := strconv.ParseFloat(value, 64)
+			if err != nil || rate < 0 || rate > 1 {
+				return config, fmt.Errorf("invalid tracing DSN: sampling must be between 0 and 1, got %q", value)
+			}
+			config.TracerSamplingRate = value
+		case key == "compression":
+			switch value {
+			case otlpCompressionGzip:
+				config.OTLPCompression = value
+			case "none", "":
+			default:
+				return config, fmt.Errorf("invalid tracing DSN: unsupported compression %q", value)
+			}
+		case key == "insecure":
+			insecure, err := strconv.ParseBool(value)
+			if err != nil {
+				return config, fmt.Errorf("invalid tracing DSN: insecure must be true or false, got %q", value)
+			}
+			config.OTLPInsecure = insecure
+		case key == "region":
+			config.SigNozRegion = value
+		case strings.HasPrefix(key, "header."):
+			if config.OTLPHeaders == nil {
+				config.OTLPHeaders = map[string]string{}
+			}
+			config.OTLPHeaders[strings.TrimPrefix(key, "header.")] = value
+		default:
+			return config, fmt.Errorf("invalid tracing DSN: unknown parameter %q", key)
+		}
+	}
+	return config, nil
+}
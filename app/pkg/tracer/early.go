@@ -0,0 +1,49 @@
+package tracer
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/embedded"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// DetectEarlyUse installs a placeholder global tracer provider that logs a
+// warning, once, when a span is started before InitTracer installs the real
+// provider. Such spans are otherwise silently dropped. Call it at the very
+// beginning of main.
+func DetectEarlyUse() {
+	otel.SetTracerProvider(&earlyUseTracerProvider{})
+}
+
+type earlyUseTracerProvider struct {
+	embedded.TracerProvider
+
+	once sync.Once
+}
+
+func (p *earlyUseTracerProvider) Tracer(name string, opts ...trace.TracerOption) trace.Tracer {
+	return &earlyUseTracer{provider: p, name: name, opts: opts}
+}
+
+type earlyUseTracer struct {
+	embedded.Tracer
+
+	provider *earlyUseTracerProvider
+	name     string
+	opts     []trace.TracerOption
+}
+
+func (t *earlyUseTracer) Start(ctx context.Context, spanName string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	// Tracers handed out before InitTracer keep pointing here, so forward to
+	// the real provider once it has been installed.
+	if global := otel.GetTracerProvider(); global != trace.TracerProvider(t.provider) {
+		return global.Tracer(t.name, t.opts...).Start(ctx, spanName, opts...)
+	}
+	t.provider.once.Do(func() {
+		packageLogger().Printf("Warning: span %q started before InitTracer, it will not be exported", spanName)
+	})
+	return noop.NewTracerProvider().Tracer(t.name).Start(ctx, spanName, opts...)
+}
@@ -0,0 +1,145 @@
+package tracer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrorStatusFunc decides the span status for an error, letting handled
+// domain errors stay out of the error rate. HTTP status codes of 400 and
+// above reach it from EndWithStatus and the gin middleware as errors with a
+// StatusCode() method.
+type ErrorStatusFunc func(err error) (codes.Code, string)
+
+var errorStatusFunc atomic.Pointer[ErrorStatusFunc]
+
+// SetErrorStatusFunc registers fn for RecordError, EndWithStatus and the gin
+// middleware. A nil fn restores DefaultErrorStatus.
+func SetErrorStatusFunc(fn ErrorStatusFunc) {
+	if fn == nil {
+		errorStatusFunc.Store(nil)
+		return
+	}
+	errorStatusFunc.Store(&fn)
+}
+
+// DefaultErrorStatus applies the HTTP class mapping: errors exposing a
+// StatusCode() below 500 leave the status unset, everything else is an error.
+func DefaultErrorStatus(err error) (codes.Code, string) {
+	var withStatus interface{ StatusCode() int }
+	if errors.As(err, &withStatus) && withStatus.StatusCode() < http.StatusInternalServerError {
+		return codes.Unset, ""
+	}
+	return codes.Error, err.Error()
+}
+
+// RecordError records err on the span in ctx and sets the span status chosen
+// by the registered ErrorStatusFunc. Nil errors are ignored. With
+// Config.MaxIdenticalErrorEvents set, repeated identical errors stop adding
+// events once the limit is reached.
+func RecordError(ctx context.Context, err error) {
+	span := trace.SpanFromContext(ctx)
+	if err == nil || !span.IsRecording() {
+		return
+	}
+	if recordErrorEvent(span, err) {
+		span.RecordError(err)
+	}
+	if code, description := errorStatus(err); code != codes.Unset {
+		span.SetStatus(code, description)
+	}
+}
+
+// errorStatus maps err with the registered ErrorStatusFunc.
+func errorStatus(err error) (codes.Code, string) {
+	if fn := errorStatusFunc.Load(); fn != nil {
+		return (*fn)(err)
+	}
+	return DefaultErrorStatus(err)
+}
+
+// httpStatusError is an HTTP response status code seen as an error, so
+// status codes go through the same ErrorStatusFunc as errors.
+type httpStatusError int
+
+func (e httpStatusError) Error() string   { return http.StatusText(int(e)) }
+func (e httpStatusError) StatusCode() int { return int(e) }
+
+// errorEventLimits holds the errorEventsProcessor of each provider with
+// Config.MaxIdenticalErrorEvents set, so the limit and the counts belong to
+// the provider that started the span.
+var errorEventLimits sync.Map // *sdktrace.TracerProvider -> *errorEventsProcessor
+
+type errorEvents struct {
+	mu         sync.Mutex
+	counts     map[string]int
+	suppressed int
+}
+
+// recordErrorEvent reports whether err should be added as an event to span.
+// Once the limit of the span's provider was reached for errors of the same
+// type and message, further ones are only counted, as
+// exception.suppressed_count on the span.
+func recordErrorEvent(span trace.Span, err error) bool {
+	tp, ok := span.TracerProvider().(*sdktrace.TracerProvider)
+	if !ok {
+		return true
+	}
+	v, ok := errorEventLimits.Load(tp)
+	if !ok {
+		return true
+	}
+	return v.(*errorEventsProcessor).record(span, err)
+}
+
+// errorEventsProcessor counts the errors recorded by RecordError per open
+// span, by type and message, and forgets them when the span ends.
+type errorEventsProcessor struct {
+	provider *sdktrace.TracerProvider
+	limit    int
+	counts   sync.Map // trace.SpanID -> *errorEvents
+}
+
+func newErrorEventsProcessor(tp *sdktrace.TracerProvider, limit int) *errorEventsProcessor {
+	p := &errorEventsProcessor{provider: tp, limit: limit}
+	errorEventLimits.Store(tp, p)
+	return p
+}
+
+func (p *errorEventsProcessor) record(span trace.Span, err error) bool {
+	v, _ := p.counts.LoadOrStore(span.SpanContext().SpanID(), &errorEvents{counts: map[string]int{}})
+	events := v.(*errorEvents)
+	key := fmt.Sprintf("%T: %s", err, err.Error())
+
+	events.mu.Lock()
+	defer events.mu.Unlock()
+	events.counts[key]++
+	if events.counts[key] <= p.limit {
+		return true
+	}
+	events.suppressed++
+	span.SetAttributes(attribute.Int("exception.suppressed_count", events.suppressed))
+	return false
+}
+
+func (*errorEventsProcessor) OnStart(context.Context, sdktrace.ReadWriteSpan) {}
+
+func (p *errorEventsProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	p.counts.Delete(s.SpanContext().SpanID())
+}
+
+func (p *errorEventsProcessor) Shutdown(context.Context) error {
+	errorEventLimits.CompareAndDelete(p.provider, p)
+	return nil
+}
+
+func (*errorEventsProcessor) ForceFlush(context.Context) error { return nil }
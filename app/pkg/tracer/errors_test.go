@@ -0,0 +1,93 @@
+package tracer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMaxIdenticalErrorEventsPerProvider(t *testing.T) {
+	limited, unlimited := tracetest.NewSpanRecorder(), tracetest.NewSpanRecorder()
+	limitedTP := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(limited))
+	unlimitedTP := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(unlimited))
+	registerSpanProcessors(limitedTP, Config{MaxIdenticalErrorEvents: 1})
+	registerSpanProcessors(unlimitedTP, Config{})
+	t.Cleanup(func() {
+		_ = limitedTP.Shutdown(context.Background())
+		_ = unlimitedTP.Shutdown(context.Background())
+	})
+
+	for _, tp := range []*sdktrace.TracerProvider{limitedTP, unlimitedTP} {
+		ctx, span := tp.Tracer("test").Start(context.Background(), "op")
+		for range 3 {
+			RecordError(ctx, errors.New("boom"))
+		}
+		span.End()
+	}
+
+	if got := len(limited.Ended()[0].Events()); got != 1 {
+		t.Errorf("limited provider recorded %d error events, want 1", got)
+	}
+	if got := len(unlimited.Ended()[0].Events()); got != 3 {
+		t.Errorf("unlimited provider recorded %d error events, want 3", got)
+	}
+
+	v, _ := errorEventLimits.Load(limitedTP)
+	v.(*errorEventsProcessor).counts.Range(func(key, _ any) bool {
+		t.Errorf("counts for ended span %v were not removed", key)
+		return true
+	})
+}
+
+var errNotFound = errors.New("order not found")
+
+func handledErrorStatus(err error) (codes.Code, string) {
+	var status interface{ StatusCode() int }
+	switch {
+	case errors.Is(err, errNotFound):
+		return codes.Unset, ""
+	case errors.As(err, &status) && status.StatusCode() == http.StatusTooManyRequests:
+		return codes.Error, "throttled"
+	}
+	return DefaultErrorStatus(err)
+}
+
+func TestStatusPathsUseErrorStatusFunc(t *testing.T) {
+	SetErrorStatusFunc(handledErrorStatus)
+	t.Cleanup(func() { SetErrorStatusFunc(nil) })
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+
+	_, span := tp.Tracer("test").Start(context.Background(), "client")
+	EndWithStatus(span, http.StatusTooManyRequests)
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(otelgin.Middleware("test", otelgin.WithTracerProvider(tp)), errorStatusMiddleware)
+	engine.GET("/orders/:id", func(c *gin.Context) {
+		_ = c.Error(errNotFound)
+		c.Status(http.StatusInternalServerError)
+	})
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders/1", nil))
+
+	ended := recorder.Ended()
+	if len(ended) != 2 {
+		t.Fatalf("got %d spans, want 2", len(ended))
+	}
+	if got := ended[0].Status(); got.Code != codes.Error || got.Description != "throttled" {
+		t.Errorf("EndWithStatus status = %v, want Error throttled", got)
+	}
+	if got := ended[1].Status().Code; got == codes.Error {
+		t.Errorf("gin span status = %v, want handled error not marked as error", got)
+	}
+}
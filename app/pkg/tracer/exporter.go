@@ -0,0 +1,217 @@
+package tracer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	texporter "github.com/GoogleCloudPlatform/opentelemetry-operations-go/exporter/trace"
+	"go.opentelemetry.io/otel/exporters/jaeger"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	jaegerOTLPDefaultEndpoint = "localhost:4317"
+	jaegerOTLPGRPCPort        = "4317"
+	jaegerCollectorPort       = "14268"
+)
+
+type toolExporter struct {
+	tool     TracingTool
+	exporter sdktrace.SpanExporter
+	canary   bool
+}
+
+// CanaryExporter additionally sends a ratio of the traces to a canary
+// collector, e.g. one being upgraded or speaking another protocol, next to
+// the exporters of TracingTool.
+type CanaryExporter struct {
+	// TracingTool is a single tool, typically OTLP_GRPC or OTLP_HTTP.
+	TracingTool  string
+	OTLPEndpoint string
+	OTLPInsecure bool
+	OTLPHeaders  map[string]string
+	// Ratio of the sampled traces, kept or dropped whole, sent to the canary.
+	Ratio float64
+}
+
+// newExporters creates an exporter for each comma separated tool of
+// config.TracingTool. Unknown tools are reported and skipped.
+func newExporters(ctx context.Context, config Config) ([]toolExporter, error) {
+	tools, err := ParseTracingTools(config.TracingTool)
+	if err != nil {
+		loggerOrDefault(config.Logger).Printf("Warning: %v", err)
+	}
+	var exporters []toolExporter
+	for _, tool := range tools {
+		toolConfig := config
+		toolConfig.TracingTool = string(tool)
+		exporter, err := newExporter(ctx, toolConfig)
+		if err != nil {
+			shutdownExporters(ctx, exporters)
+			return nil, err
+		}
+		if exporter != nil {
+			exporters = append(exporters, toolExporter{tool: tool, exporter: exporter})
+		}
+	}
+	if config.Canary != nil && len(exporters) > 0 {
+		canaryConfig := config
+		canaryConfig.TracingTool = strings.ToUpper(strings.TrimSpace(config.Canary.TracingTool))
+		canaryConfig.OTLPEndpoint = config.Canary.OTLPEndpoint
+		canaryConfig.OTLPEndpointSRV = ""
+		canaryConfig.OTLPInsecure = config.Canary.OTLPInsecure
+		canaryConfig.OTLPHeaders = config.Canary.OTLPHeaders
+		exporter, err := newExporter(ctx, canaryConfig)
+		if err != nil {
+			shutdownExporters(ctx, exporters)
+			return nil, fmt.Errorf("canary exporter: %w", err)
+		}
+		if exporter != nil {
+			exporters = append(exporters, toolExporter{tool: TracingTool(canaryConfig.TracingTool), exporter: exporter, canary: true})
+		}
+	}
+	return exporters, nil
+}
+
+func shutdownExporters(ctx context.Context, exporters []toolExporter) {
+	for _, e := range exporters {
+		_ = e.exporter.Shutdown(ctx)
+	}
+}
+
+// newExporter creates the span exporter for the single tool in
+// config.TracingTool. It returns a nil exporter when the tool cannot be used.
+func newExporter(ctx context.Context, config Config) (sdktrace.SpanExporter, error) {
+	config = withDiscoveredEndpoint(config)
+	switch TracingTool(config.TracingTool) {
+	case ToolGCP:
+		if config.GoogleCloudProject == "" {
+			return nil, nil
+		}
+		exporter, err := texporter.New(texporter.WithProjectID(config.GoogleCloudProject))
+		if err != nil {
+			return nil, err
+		}
+		loggerOrDefault(config.Logger).Printf("GCP trace exporter created successfully")
+		return exporter, nil
+	case ToolStdout:
+		loggerOrDefault(config.Logger).Printf("infrastructureconfiguration.TracingTool CCC: %s", config.TracingTool)
+		return newStdoutExporter(config)
+	case ToolFile:
+		return newFileExporter(ctx, config)
+	case ToolKafka:
+		return newKafkaExporter(ctx, config)
+	case ToolJaeger:
+		if config.JaegerLegacy {
+			return newJaegerExporter(config)
+		}
+		return newJaegerOTLPExporter(ctx, config)
+	case ToolSigNoz:
+		return newSigNozExporter(ctx, config)
+	case ToolOTLP, ToolOTLPGRPC, ToolOTLPHTTP:
+		return newOTLPExporter(ctx, config)
+	case ToolCloudEvents:
+		return newCloudEventsExporter(config)
+	case ToolLocal:
+		return newLocalExporter(), nil
+	}
+	return nil, nil
+}
+
+// newStdoutExporter writes spans to stdout, or to config.StdoutPath when set.
+// The file is closed when the exporter shuts down.
+func newStdoutExporter(config Config) (sdktrace.SpanExporter, error) {
+	var opts []stdouttrace.Option
+	if config.StdoutPrettyPrint == nil || *config.StdoutPrettyPrint {
+		opts = append(opts, stdouttrace.WithPrettyPrint())
+	}
+	if config.StdoutPath == "" {
+		return stdouttrace.New(opts...)
+	}
+
+	file, err := os.OpenFile(config.StdoutPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open stdout exporter file: %w", err)
+	}
+	exporter, err := stdouttrace.New(append(opts, stdouttrace.WithWriter(file))...)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return fileClosingExporter{SpanExporter: exporter, file: file}, nil
+}
+
+type fileClosingExporter struct {
+	sdktrace.SpanExporter
+
+	file *os.File
+}
+
+func (e fileClosingExporter) Shutdown(ctx context.Context) error {
+	err := e.SpanExporter.Shutdown(ctx)
+	if closeErr := e.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// newJaegerOTLPExporter sends spans to the OTLP receiver built into Jaeger
+// (1.35+), on port 4317 for gRPC or 4318 for HTTP. Only the HTTP protocol
+// goes through config.ProxyURL; gRPC connections do not honour it.
+func newJaegerOTLPExporter(ctx context.Context, config Config) (sdktrace.SpanExporter, error) {
+	config.OTLPEndpoint, config.OTLPInsecure = jaegerOTLPEndpoint(config.JaegerEndpoint, config.OTLPInsecure, loggerOrDefault(config.Logger))
+	return newOTLPExporter(ctx, config)
+}
+
+// jaegerOTLPEndpoint maps JaegerEndpoint to Jaeger's OTLP receiver. Legacy
+// collector endpoints such as http://jaeger:14268/api/traces are moved to
+// port 4317 on the same host, and http:// endpoints are dialed insecurely.
+func jaegerOTLPEndpoint(endpoint string, insecure bool, logger Logger) (string, bool) {
+	if endpoint == "" {
+		return jaegerOTLPDefaultEndpoint, true
+	}
+	host := endpoint
+	if strings.Contains(endpoint, "://") {
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return endpoint, insecure
+		}
+		insecure = insecure || u.Scheme == "http"
+		if u.Port() == otlpHTTPPort {
+			return endpoint, insecure
+		}
+		host = u.Host
+	}
+	hostname, port, err := net.SplitHostPort(host)
+	if err != nil {
+		return host, insecure
+	}
+	if port == jaegerCollectorPort {
+		logger.Printf("Jaeger collector endpoint %s is deprecated, sending OTLP to port %s instead", endpoint, jaegerOTLPGRPCPort)
+		port = jaegerOTLPGRPCPort
+	}
+	return net.JoinHostPort(hostname, port), insecure
+}
+
+// newJaegerExporter sends spans to the Jaeger collector, through
+// config.ProxyURL when set. It uses the deprecated Jaeger exporter, which
+// posts Thrift to the collector endpoint (e.g. http://jaeger:14268/api/traces).
+func newJaegerExporter(config Config) (sdktrace.SpanExporter, error) {
+	opts := []jaeger.CollectorEndpointOption{jaeger.WithEndpoint(config.JaegerEndpoint)}
+	if config.ProxyURL != "" {
+		proxy, err := url.Parse(config.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = http.ProxyURL(proxy)
+		opts = append(opts, jaeger.WithHTTPClient(&http.Client{Transport: transport}))
+	}
+	return jaeger.New(jaeger.WithCollectorEndpoint(opts...))
+}
@@ -0,0 +1,165 @@
+package tracer
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const defaultTraceFilePath = "traces.otlp.json.gz"
+
+// newFileExporter writes spans to config.TraceFilePath as gzip-compressed
+// OTLP/JSON, one export request per line. Once the file reaches
+// TraceFileMaxSizeMB it is rotated to <path>.1, <path>.2, ... keeping at most
+// TraceFileMaxBackups old files (all when 0).
+func newFileExporter(ctx context.Context, config Config) (*otlptrace.Exporter, error) {
+	path := config.TraceFilePath
+	if path == "" {
+		path = defaultTraceFilePath
+	}
+	client := &fileClient{
+		path:       path,
+		maxSize:    int64(config.TraceFileMaxSizeMB) * 1024 * 1024,
+		maxBackups: config.TraceFileMaxBackups,
+	}
+	loggerOrDefault(config.Logger).Printf("Writing traces to %s", path)
+	return otlptrace.New(ctx, client)
+}
+
+// fileClient is an otlptrace.Client writing to rotating gzip files.
+type fileClient struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	mu      sync.Mutex
+	file    *os.File
+	gz      *gzip.Writer
+	size    int64
+	stopped bool
+}
+
+var _ otlptrace.Client = (*fileClient)(nil)
+
+func (c *fileClient) Start(context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopped = false
+	return c.open()
+}
+
+func (c *fileClient) Stop(context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopped = true
+	return c.close()
+}
+
+func (c *fileClient) UploadTraces(_ context.Context, protoSpans []*tracepb.ResourceSpans) error {
+	line, err := protojson.Marshal(&coltracepb.ExportTraceServiceRequest{ResourceSpans: protoSpans})
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stopped {
+		return errors.New("trace file exporter is stopped")
+	}
+	if c.gz == nil {
+		// A failed rotation could not reopen the file; try again.
+		if err := c.open(); err != nil {
+			return err
+		}
+	}
+	if _, err := c.gz.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	// Flush per batch so a crash loses at most the batch in flight.
+	if err := c.gz.Flush(); err != nil {
+		return err
+	}
+	if c.maxSize > 0 && c.size >= c.maxSize {
+		return c.rotate()
+	}
+	return nil
+}
+
+// Write counts the compressed bytes reaching the file.
+func (c *fileClient) Write(p []byte) (int, error) {
+	n, err := c.file.Write(p)
+	c.size += int64(n)
+	return n, err
+}
+
+func (c *fileClient) open() error {
+	file, err := os.OpenFile(c.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	// Appending starts a new gzip member, which gzip readers handle.
+	c.file, c.size = file, info.Size()
+	c.gz = gzip.NewWriter(c)
+	return nil
+}
+
+func (c *fileClient) close() error {
+	if c.gz == nil {
+		return nil
+	}
+	err := c.gz.Close()
+	if closeErr := c.file.Close(); err == nil {
+		err = closeErr
+	}
+	c.gz, c.file = nil, nil
+	return err
+}
+
+// rotate moves the full file to <path>.1 and opens a new one. When moving
+// fails it still reopens whatever file is at the path, so exports carry on.
+func (c *fileClient) rotate() error {
+	return errors.Join(c.shiftBackups(), c.open())
+}
+
+func (c *fileClient) shiftBackups() error {
+	if err := c.close(); err != nil {
+		return err
+	}
+	if c.maxBackups > 0 {
+		_ = os.Remove(c.backupPath(c.maxBackups))
+	}
+	for i := c.lastBackup(); i >= 1; i-- {
+		if err := os.Rename(c.backupPath(i), c.backupPath(i+1)); err != nil {
+			return err
+		}
+	}
+	return os.Rename(c.path, c.backupPath(1))
+}
+
+// lastBackup returns the highest existing backup index.
+func (c *fileClient) lastBackup() int {
+	n := 0
+	for {
+		if _, err := os.Stat(c.backupPath(n + 1)); err != nil {
+			return n
+		}
+		n++
+	}
+}
+
+func (c *fileClient) backupPath(i int) string {
+	return fmt.Sprintf("%s.%d", c.path, i)
+}
@@ -0,0 +1,35 @@
+package tracer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func TestFileClientKeepsWritingAfterFailedRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "traces.json.gz")
+	client := &fileClient{path: path, maxSize: 1}
+	if err := client.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Stop(context.Background()) })
+	spans := []*tracepb.ResourceSpans{{}}
+	// Removing the open file makes moving it to <path>.1 fail.
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.UploadTraces(context.Background(), spans); err == nil {
+		t.Error("expected the rotation error")
+	}
+	if err := client.UploadTraces(context.Background(), spans); err != nil {
+		t.Errorf("export after a failed rotation: %v", err)
+	}
+	if _, err := os.Stat(path + ".1"); err != nil {
+		t.Errorf("rotation did not resume: %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package tracer
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const cloudTraceContextHeader = "X-Cloud-Trace-Context"
+
+// CloudTraceContext propagates trace context in the X-Cloud-Trace-Context
+// header used by Google Cloud ("TRACE_ID/SPAN_ID;o=OPTIONS", with a hex
+// trace ID, a decimal span ID and an options bitmask whose bit 1 means
+// sampled).
+type CloudTraceContext struct{}
+
+var _ propagation.TextMapPropagator = CloudTraceContext{}
+
+func (CloudTraceContext) Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+	spanID := sc.SpanID()
+	sampled := 0
+	if sc.IsSampled() {
+		sampled = 1
+	}
+	carrier.Set(cloudTraceContextHeader, fmt.Sprintf("%s/%d;o=%d", sc.TraceID(), binary.BigEndian.Uint64(spanID[:]), sampled))
+}
+
+func (CloudTraceContext) Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	sc, ok := parseCloudTraceContext(carrier.Get(cloudTraceContextHeader))
+	if !ok {
+		return ctx
+	}
+	return trace.ContextWithRemoteSpanContext(ctx, sc)
+}
+
+func (CloudTraceContext) Fields() []string {
+	return []string{cloudTraceContextHeader}
+}
+
+func parseCloudTraceContext(header string) (trace.SpanContext, bool) {
+	traceHex, rest, ok := strings.Cut(header, "/")
+	if !ok {
+		return trace.SpanContext{}, false
+	}
+	traceID, err := trace.TraceIDFromHex(traceHex)
+	if err != nil {
+		return trace.SpanContext{}, false
+	}
+	spanDec, options, _ := strings.Cut(rest, ";")
+	spanNum, err := strconv.ParseUint(spanDec, 10, 64)
+	if err != nil || spanNum == 0 {
+		return trace.SpanContext{}, false
+	}
+	var spanID trace.SpanID
+	binary.BigEndian.PutUint64(spanID[:], spanNum)
+
+	var flags trace.TraceFlags
+	if cloudTraceSampled(options) {
+		flags = trace.FlagsSampled
+	}
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: flags,
+		Remote:     true,
+	})
+	return sc, sc.IsValid()
+}
+
+// cloudTraceSampled reports whether the sampled bit is set in the o= options
+// bitmask, e.g. "o=1" or "o=3". Other options are ignored.
+func cloudTraceSampled(options string) bool {
+	for _, option := range strings.Split(options, ";") {
+		value, ok := strings.CutPrefix(strings.TrimSpace(option), "o=")
+		if !ok {
+			continue
+		}
+		mask, err := strconv.ParseUint(value, 10, 64)
+		return err == nil && mask&1 == 1
+	}
+	return false
+}
+
+// ExtractCloudTask returns ctx continuing the trace that enqueued a Cloud
+// Tasks job or triggered a Cloud Scheduler request. A W3C traceparent header
+// takes precedence over X-Cloud-Trace-Context.
+func ExtractCloudTask(ctx context.Context, r *http.Request) context.Context {
+	carrier := propagation.HeaderCarrier(r.Header)
+	for _, propagator := range []propagation.TextMapPropagator{propagation.TraceContext{}, CloudTraceContext{}} {
+		// Extract onto an empty context so a span already in ctx is not
+		// mistaken for an upstream one.
+		sc := trace.SpanContextFromContext(propagator.Extract(context.Background(), carrier))
+		if sc.IsValid() {
+			return trace.ContextWithRemoteSpanContext(ctx, sc)
+		}
+	}
+	return ctx
+}
@@ -0,0 +1,28 @@
+package tracer
+
+import "testing"
+
+func TestParseCloudTraceContextSampled(t *testing.T) {
+	const traceID = "105445aa7843bc8bf206b12000100000"
+	tests := []struct {
+		header  string
+		sampled bool
+	}{
+		{traceID + "/1", false},
+		{traceID + "/1;o=0", false},
+		{traceID + "/1;o=1", true},
+		{traceID + "/1;o=3", true},
+		{traceID + "/1;o=2", false},
+		{traceID + "/1;o=x", false},
+	}
+	for _, tt := range tests {
+		sc, ok := parseCloudTraceContext(tt.header)
+		if !ok {
+			t.Errorf("parseCloudTraceContext(%q) failed", tt.header)
+			continue
+		}
+		if sc.IsSampled() != tt.sampled {
+			t.Errorf("parseCloudTraceContext(%q) sampled = %t, want %t", tt.header, sc.IsSampled(), tt.sampled)
+		}
+	}
+}
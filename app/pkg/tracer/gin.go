@@ -0,0 +1,219 @@
+package tracer
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const redactedValue = "REDACTED"
+
+// errorStatusMiddleware sets the server span status chosen by the registered
+// ErrorStatusFunc for the last gin error, or else for a 4xx or 5xx response.
+// otelgin marks 5xx responses and gin errors as errors after this runs, so
+// when the mapping leaves those unset the span is marked Ok, which is final.
+func errorStatusMiddleware(c *gin.Context) {
+	c.Next()
+	span := trace.SpanFromContext(c.Request.Context())
+	if !span.IsRecording() {
+		return
+	}
+	status := c.Writer.Status()
+	var err error
+	if last := c.Errors.Last(); last != nil {
+		err = last.Err
+	} else if status >= http.StatusBadRequest {
+		err = httpStatusError(status)
+	}
+	if err == nil {
+		return
+	}
+	setServerErrorStatus(span, err, len(c.Errors) > 0 || status >= http.StatusInternalServerError)
+}
+
+// setServerErrorStatus sets the span status chosen by the registered
+// ErrorStatusFunc for err. failed reports whether the instrumentation marks
+// the span as an error after the handler; a mapping that leaves it unset then
+// marks the span Ok, which is final.
+func setServerErrorStatus(span trace.Span, err error, failed bool) {
+	code, description := errorStatus(err)
+	if code == codes.Unset && failed {
+		code = codes.Ok
+	}
+	if code != codes.Unset {
+		span.SetStatus(code, description)
+	}
+}
+
+// fullURLMiddleware sets http.full_url on recording spans: the route template
+// by default, or the request URL with the redact query parameters masked when
+// full is set.
+func fullURLMiddleware(full bool, redact []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		span := trace.SpanFromContext(c.Request.Context())
+		if span.IsRecording() {
+			span.SetAttributes(attribute.String("http.full_url", requestURL(c, full, redact)))
+		}
+		c.Next()
+	}
+}
+
+func requestURL(c *gin.Context, full bool, redact []string) string {
+	if !full {
+		if route := c.FullPath(); route != "" {
+			return route
+		}
+		return c.Request.URL.Path
+	}
+	return redactedURL(c.Request.URL, redact)
+}
+
+// redactedURL returns u with the values of the redact query parameters
+// replaced.
+func redactedURL(u *url.URL, redact []string) string {
+	if len(redact) == 0 || u.RawQuery == "" {
+		return u.String()
+	}
+	copied := *u
+	query := copied.Query()
+	for _, name := range redact {
+		if query.Has(name) {
+			query.Set(name, redactedValue)
+		}
+	}
+	copied.RawQuery = query.Encode()
+	return copied.String()
+}
+
+// handlerNameMiddleware records the name of the handler serving the route as
+// code.function. HandlerName uses reflection, so it is opt-in.
+func handlerNameMiddleware(c *gin.Context) {
+	span := trace.SpanFromContext(c.Request.Context())
+	if span.IsRecording() {
+		span.SetAttributes(attribute.String("code.function", c.HandlerName()))
+	}
+	c.Next()
+}
+
+// routeParamsMiddleware records gin route parameters as
+// http.route.param.<name>. When allowlist is not empty only the listed
+// parameters are recorded.
+func routeParamsMiddleware(allowlist []string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(allowlist))
+	for _, name := range allowlist {
+		allowed[name] = true
+	}
+	return func(c *gin.Context) {
+		span := trace.SpanFromContext(c.Request.Context())
+		if span.IsRecording() {
+			for _, param := range c.Params {
+				if len(allowed) == 0 || allowed[param.Key] {
+					span.SetAttributes(attribute.String("http.route.param."+param.Key, param.Value))
+				}
+			}
+		}
+		c.Next()
+	}
+}
+
+// apiVersionMiddleware records the version returned by extract as
+// api.version. Empty versions are not recorded.
+func apiVersionMiddleware(extract func(*gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		span := trace.SpanFromContext(c.Request.Context())
+		if span.IsRecording() {
+			if version := extract(c); version != "" {
+				span.SetAttributes(attribute.String("api.version", version))
+			}
+		}
+		c.Next()
+	}
+}
+
+// doNotTraceMiddleware honours an upstream do-not-trace header: the request
+// context is marked with WithDoNotTrace and the baggage header is removed
+// before otelgin extracts it. It must run before otelgin.
+func doNotTraceMiddleware(header string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader(header) != "" {
+			c.Request.Header.Del("baggage")
+			c.Request = c.Request.WithContext(WithDoNotTrace(c.Request.Context()))
+		}
+		c.Next()
+	}
+}
+
+const maxSessionBaggageLength = 256
+
+// sessionBaggageMiddleware copies the session ID from header or cookie into
+// the request baggage under key, so it propagates to downstream services.
+// It must run after otelgin, which replaces the baggage with the incoming
+// one.
+func sessionBaggageMiddleware(header, cookie, key string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value := ""
+		if header != "" {
+			value = c.GetHeader(header)
+		}
+		if value == "" && cookie != "" {
+			value, _ = c.Cookie(cookie)
+		}
+		if value != "" && len(value) <= maxSessionBaggageLength {
+			ctx := c.Request.Context()
+			if member, err := baggage.NewMemberRaw(key, value); err == nil {
+				if bag, err := baggage.FromContext(ctx).SetMember(member); err == nil {
+					c.Request = c.Request.WithContext(baggage.ContextWithBaggage(ctx, bag))
+				}
+			}
+		}
+		c.Next()
+	}
+}
+
+// queueWaitMiddleware records the time since acceptedAt as queue.wait_ms.
+func queueWaitMiddleware(acceptedAt func(*gin.Context) time.Time) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if accepted := acceptedAt(c); !accepted.IsZero() {
+			RecordQueueWait(c.Request.Context(), accepted)
+		}
+		c.Next()
+	}
+}
+
+// ipEnrichmentMiddleware adds the attributes enrich returns for the client
+// IP to recording spans.
+func ipEnrichmentMiddleware(enrich func(ip string) []attribute.KeyValue, logger Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		span := trace.SpanFromContext(c.Request.Context())
+		if span.IsRecording() {
+			span.SetAttributes(enrichIP(enrich, c.ClientIP(), logger)...)
+		}
+		c.Next()
+	}
+}
+
+func enrichIP(enrich func(ip string) []attribute.KeyValue, ip string, logger Logger) (attrs []attribute.KeyValue) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Printf("IP enricher panicked, skipping attributes: %v", r)
+			attrs = nil
+		}
+	}()
+	return enrich(ip)
+}
+
+// spanEnricherMiddleware calls enrich with recording request spans.
+func spanEnricherMiddleware(enrich func(*gin.Context, trace.Span)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if span := trace.SpanFromContext(c.Request.Context()); span.IsRecording() {
+			enrich(c, span)
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,68 @@
+package tracer
+
+import (
+	"context"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/stats"
+)
+
+// NewGRPCMessageSizeHandler returns a gRPC stats handler that records the
+// total uncompressed message sizes of each RPC as rpc.grpc.request.size and
+// rpc.grpc.response.size on the RPC span. It is opt-in because of the per
+// message overhead. Register it next to the handler creating the span, e.g.
+//
+//	grpc.NewServer(
+//		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+//		grpc.StatsHandler(tracer.NewGRPCMessageSizeHandler()),
+//	)
+//
+// Works the same with grpc.WithStatsHandler on clients.
+func NewGRPCMessageSizeHandler() stats.Handler {
+	return grpcMessageSizeHandler{}
+}
+
+type grpcMessageSizesKey struct{}
+
+type grpcMessageSizes struct {
+	request, response atomic.Int64
+}
+
+type grpcMessageSizeHandler struct{}
+
+func (grpcMessageSizeHandler) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return context.WithValue(ctx, grpcMessageSizesKey{}, &grpcMessageSizes{})
+}
+
+func (grpcMessageSizeHandler) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	sizes, ok := ctx.Value(grpcMessageSizesKey{}).(*grpcMessageSizes)
+	if !ok {
+		return
+	}
+	var request bool
+	var length int
+	switch s := s.(type) {
+	case *stats.InPayload:
+		request, length = !s.IsClient(), s.Length
+	case *stats.OutPayload:
+		request, length = s.IsClient(), s.Length
+	default:
+		return
+	}
+	// Update the span on every message rather than at stats.End, which the
+	// span creating handler may see first and end the span on.
+	span := trace.SpanFromContext(ctx)
+	if request {
+		span.SetAttributes(attribute.Int64("rpc.grpc.request.size", sizes.request.Add(int64(length))))
+	} else {
+		span.SetAttributes(attribute.Int64("rpc.grpc.response.size", sizes.response.Add(int64(length))))
+	}
+}
+
+func (grpcMessageSizeHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (grpcMessageSizeHandler) HandleConn(context.Context, stats.ConnStats) {}
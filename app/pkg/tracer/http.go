@@ -0,0 +1,104 @@
+package tracer
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/felixge/httpsnoop"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// httpURLSettings are the http.full_url settings of the last InitTracer, for
+// Middleware, which takes no Config.
+type httpURLSettings struct {
+	full   bool
+	redact []string
+}
+
+var installedURLSettings atomic.Pointer[httpURLSettings]
+
+// Middleware is the net/http counterpart of the gin integration. It extracts
+// the incoming trace context and starts a server span. Like the gin
+// middleware it records http.full_url as the matched ServeMux pattern or the
+// path, or the redacted URL with Config.RecordFullURL, and sets the status
+// chosen by the registered ErrorStatusFunc for 4xx and 5xx responses.
+func Middleware(next http.Handler) http.Handler {
+	enrich := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		span := trace.SpanFromContext(r.Context())
+		if !span.IsRecording() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		status := http.StatusOK
+		wroteHeader := false
+		w = httpsnoop.Wrap(w, httpsnoop.Hooks{
+			WriteHeader: func(write httpsnoop.WriteHeaderFunc) httpsnoop.WriteHeaderFunc {
+				return func(code int) {
+					if !wroteHeader && code >= http.StatusOK {
+						status, wroteHeader = code, true
+					}
+					write(code)
+				}
+			},
+		})
+		// ServeMux sets r.Pattern while routing, so read it afterwards.
+		next.ServeHTTP(w, r)
+		span.SetAttributes(attribute.String("http.full_url", handlerURL(r)))
+		if status >= http.StatusBadRequest {
+			setServerErrorStatus(span, httpStatusError(status), status >= http.StatusInternalServerError)
+		}
+	})
+	return otelhttp.NewHandler(enrich, "http-server")
+}
+
+func handlerURL(r *http.Request) string {
+	settings := installedURLSettings.Load()
+	if settings != nil && settings.full {
+		return redactedURL(r.URL, settings.redact)
+	}
+	if r.Pattern != "" {
+		return r.Pattern
+	}
+	return r.URL.Path
+}
+
+// PeerServiceMapper maps an outbound host to the logical service name shown
+// in the backend's dependency graph. Returning "" falls back to the host.
+type PeerServiceMapper func(host string) string
+
+// NewTransport wraps base (http.DefaultTransport when nil) so outbound
+// requests get a client span, carry the trace context and record the target
+// host as net.peer.name and peer.service, mapped through mapper when set.
+func NewTransport(base http.RoundTripper, mapper PeerServiceMapper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return otelhttp.NewTransport(peerTransport{next: base, mapper: mapper})
+}
+
+// peerTransport runs inside otelhttp, where the request context already
+// holds the client span.
+type peerTransport struct {
+	next   http.RoundTripper
+	mapper PeerServiceMapper
+}
+
+func (t peerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	span := trace.SpanFromContext(r.Context())
+	if span.IsRecording() {
+		host := r.URL.Hostname()
+		service := host
+		if t.mapper != nil {
+			if mapped := t.mapper(host); mapped != "" {
+				service = mapped
+			}
+		}
+		span.SetAttributes(
+			attribute.String("net.peer.name", host),
+			attribute.String("peer.service", service),
+		)
+	}
+	return t.next.RoundTrip(r)
+}
@@ -0,0 +1,55 @@
+package tracer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddlewareURLAndStatus(t *testing.T) {
+	SetErrorStatusFunc(handledErrorStatus)
+	t.Cleanup(func() { SetErrorStatusFunc(nil) })
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	handler := Middleware(mux)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders/1?token=secret", nil))
+	installedURLSettings.Store(&httpURLSettings{full: true, redact: []string{"token"}})
+	t.Cleanup(func() { installedURLSettings.Store(nil) })
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders/1?token=secret", nil))
+
+	ended := recorder.Ended()
+	if len(ended) != 2 {
+		t.Fatalf("got %d spans, want 2", len(ended))
+	}
+	for i, want := range []string{"GET /orders/{id}", "/orders/1?token=REDACTED"} {
+		var got string
+		for _, kv := range ended[i].Attributes() {
+			if kv.Key == "http.full_url" {
+				got = kv.Value.AsString()
+			}
+		}
+		if got != want {
+			t.Errorf("span %d http.full_url = %q, want %q", i, got, want)
+		}
+		if status := ended[i].Status(); status.Code != codes.Error || status.Description != "throttled" {
+			t.Errorf("span %d status = %v, want Error throttled", i, status)
+		}
+	}
+}
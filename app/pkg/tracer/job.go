@@ -0,0 +1,48 @@
+package tracer
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var installedProvider atomic.Pointer[sdktrace.TracerProvider]
+
+// StartJobSpan starts the root span of one run of a scheduled job, with
+// faas.trigger=timer, job.name and a random job.run_id. Every call starts a
+// new trace, so overlapping runs never share one and can be told apart by
+// job.run_id; a span already in ctx is linked rather than used as parent.
+// Retries within a run belong in child spans, while a job re-run by the
+// scheduler is a new run. Short-lived jobs should call FlushTraces before
+// exiting.
+func StartJobSpan(ctx context.Context, jobName string) (context.Context, trace.Span) {
+	opts := []trace.SpanStartOption{
+		trace.WithNewRoot(),
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(
+			semconv.FaaSTriggerTimer,
+			attribute.String("job.name", jobName),
+			attribute.String("job.run_id", uuid.NewString()),
+		),
+	}
+	if parent := trace.SpanContextFromContext(ctx); parent.IsValid() {
+		opts = append(opts, trace.WithLinks(trace.Link{SpanContext: parent}))
+	}
+	return StartSpan(ctx, jobName, opts...)
+}
+
+// FlushTraces synchronously exports the spans buffered by the provider
+// installed by InitTracer, e.g. at the end of a job or CLI command. It is a
+// no-op before InitTracer.
+func FlushTraces(ctx context.Context) error {
+	tp := installedProvider.Load()
+	if tp == nil {
+		return nil
+	}
+	return tp.ForceFlush(ctx)
+}
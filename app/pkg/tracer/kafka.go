@@ -0,0 +1,153 @@
+package tracer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// defaultKafkaMaxMessageBytes stays below the broker's default
+	// message.max.bytes of 1048588.
+	defaultKafkaMaxMessageBytes = 1000000
+	// kafkaRecordOverhead is room for the record framing on top of the
+	// value when sizing the writer's batches.
+	kafkaRecordOverhead = 1024
+	// Exports write synchronously from the batch span processor, so the
+	// writer must not sit on a batch for kafka-go's default of 1s. Each
+	// message already carries many spans.
+	kafkaBatchTimeout = 10 * time.Millisecond
+	kafkaBatchSize    = 10
+)
+
+// newKafkaExporter produces spans to config.KafkaTopic as OTLP protobuf
+// ExportTraceServiceRequest messages, the otlp_proto encoding read by the
+// collector's kafka receiver. Span batches are split into messages of at most
+// config.KafkaMaxMessageBytes.
+func newKafkaExporter(ctx context.Context, config Config) (*otlptrace.Exporter, error) {
+	if len(config.KafkaBrokers) == 0 || config.KafkaTopic == "" {
+		return nil, errors.New("kafka brokers and topic must be configured")
+	}
+	maxBytes := config.KafkaMaxMessageBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultKafkaMaxMessageBytes
+	}
+	writer := &kafka.Writer{
+		Addr:         kafka.TCP(config.KafkaBrokers...),
+		Topic:        config.KafkaTopic,
+		Balancer:     &kafka.LeastBytes{},
+		RequiredAcks: kafka.RequireAll,
+		BatchTimeout: kafkaBatchTimeout,
+		BatchSize:    kafkaBatchSize,
+		BatchBytes:   int64(maxBytes + kafkaRecordOverhead),
+	}
+	if config.KafkaTransport != nil {
+		writer.Transport = config.KafkaTransport
+	}
+	loggerOrDefault(config.Logger).Printf("Producing traces to kafka topic %s", config.KafkaTopic)
+	return otlptrace.New(ctx, &kafkaClient{writer: writer, maxBytes: maxBytes})
+}
+
+// kafkaClient is an otlptrace.Client producing to a kafka topic.
+type kafkaClient struct {
+	writer   *kafka.Writer
+	maxBytes int
+}
+
+var _ otlptrace.Client = (*kafkaClient)(nil)
+
+func (c *kafkaClient) Start(context.Context) error { return nil }
+
+// Stop flushes pending messages and closes the producer.
+func (c *kafkaClient) Stop(context.Context) error {
+	return c.writer.Close()
+}
+
+func (c *kafkaClient) UploadTraces(ctx context.Context, protoSpans []*tracepb.ResourceSpans) error {
+	values, err := splitTraceRequests(protoSpans, c.maxBytes)
+	if len(values) == 0 {
+		return err
+	}
+	messages := make([]kafka.Message, len(values))
+	for i, value := range values {
+		messages[i] = kafka.Message{Value: value}
+	}
+	if werr := c.writer.WriteMessages(ctx, messages...); werr != nil {
+		return errors.Join(fmt.Errorf("produce traces to kafka: %w", werr), err)
+	}
+	return err
+}
+
+// kafkaSpan is one span with the resource and scope it belongs to.
+type kafkaSpan struct {
+	resource *tracepb.ResourceSpans
+	scope    *tracepb.ScopeSpans
+	span     *tracepb.Span
+}
+
+// splitTraceRequests marshals protoSpans into ExportTraceServiceRequest
+// messages of at most maxBytes, halving the spans of a request until it
+// fits. A single span over maxBytes is dropped and reported in the error.
+func splitTraceRequests(protoSpans []*tracepb.ResourceSpans, maxBytes int) ([][]byte, error) {
+	var spans []kafkaSpan
+	for _, rs := range protoSpans {
+		for _, ss := range rs.ScopeSpans {
+			for _, span := range ss.Spans {
+				spans = append(spans, kafkaSpan{resource: rs, scope: ss, span: span})
+			}
+		}
+	}
+	var (
+		values [][]byte
+		errs   []error
+	)
+	var split func(spans []kafkaSpan)
+	split = func(spans []kafkaSpan) {
+		if len(spans) == 0 {
+			return
+		}
+		value, err := proto.Marshal(traceRequest(spans))
+		switch {
+		case err != nil:
+			errs = append(errs, err)
+		case len(value) <= maxBytes:
+			values = append(values, value)
+		case len(spans) == 1:
+			errs = append(errs, fmt.Errorf("span %q is %d bytes, over the kafka message limit of %d", spans[0].span.Name, len(value), maxBytes))
+		default:
+			split(spans[:len(spans)/2])
+			split(spans[len(spans)/2:])
+		}
+	}
+	split(spans)
+	return values, errors.Join(errs...)
+}
+
+// traceRequest groups spans, in order, back under their resource and scope.
+func traceRequest(spans []kafkaSpan) *coltracepb.ExportTraceServiceRequest {
+	req := &coltracepb.ExportTraceServiceRequest{}
+	var (
+		rs *tracepb.ResourceSpans
+		ss *tracepb.ScopeSpans
+	)
+	for _, s := range spans {
+		if rs == nil || s.resource.Resource != rs.Resource || s.resource.SchemaUrl != rs.SchemaUrl {
+			rs = &tracepb.ResourceSpans{Resource: s.resource.Resource, SchemaUrl: s.resource.SchemaUrl}
+			req.ResourceSpans = append(req.ResourceSpans, rs)
+			ss = nil
+		}
+		if ss == nil || s.scope.Scope != ss.Scope || s.scope.SchemaUrl != ss.SchemaUrl {
+			ss = &tracepb.ScopeSpans{Scope: s.scope.Scope, SchemaUrl: s.scope.SchemaUrl}
+			rs.ScopeSpans = append(rs.ScopeSpans, ss)
+		}
+		ss.Spans = append(ss.Spans, s.span)
+	}
+	return req
+}
@@ -0,0 +1,42 @@
+package tracer
+
+import (
+	"strings"
+	"testing"
+
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestSplitTraceRequests(t *testing.T) {
+	scope := &tracepb.ScopeSpans{}
+	for i := 0; i < 50; i++ {
+		scope.Spans = append(scope.Spans, &tracepb.Span{Name: strings.Repeat("s", 100)})
+	}
+	big := &tracepb.ScopeSpans{Spans: []*tracepb.Span{{Name: strings.Repeat("b", 2000)}}}
+	spans := []*tracepb.ResourceSpans{{ScopeSpans: []*tracepb.ScopeSpans{scope, big}}}
+
+	values, err := splitTraceRequests(spans, 1000)
+	if err == nil {
+		t.Error("expected an error for the span over the limit")
+	}
+	total := 0
+	for _, value := range values {
+		if len(value) > 1000 {
+			t.Errorf("message is %d bytes, over the limit", len(value))
+		}
+		req := &coltracepb.ExportTraceServiceRequest{}
+		if err := proto.Unmarshal(value, req); err != nil {
+			t.Fatal(err)
+		}
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				total += len(ss.Spans)
+			}
+		}
+	}
+	if total != 50 {
+		t.Errorf("got %d spans across messages, want 50", total)
+	}
+}
@@ -0,0 +1,108 @@
+package tracer
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const samplingKeepKey = attribute.Key("sampling.keep")
+
+const (
+	// keepRootMaxAge is how long a local root that never ends, e.g. one
+	// leaked by a goroutine, is tracked before it is forgotten.
+	keepRootMaxAge = 10 * time.Minute
+	// maxKeepRoots bounds the open local roots tracked at once. Roots
+	// starting while it is reached cannot be marked.
+	maxKeepRoots = 10000
+	// keepSweepInterval spaces out the scans for roots to forget.
+	keepSweepInterval = time.Minute
+)
+
+// MarkKeep flags the trace of the span in ctx as one a downstream tail
+// sampler must keep, by setting sampling.keep=true on the span. With
+// Config.KeepTraces enabled the flag is also copied to the local root span.
+// Traces dropped by head sampling cannot be recovered; see KeepTraceEvents.
+func MarkKeep(ctx context.Context) {
+	trace.SpanFromContext(ctx).SetAttributes(samplingKeepKey.Bool(true))
+}
+
+// keepTraceProcessor sets sampling.keep=true on the open local root of a
+// trace once a span of it ends with that flag or with one of events.
+// Roots ending before such a span cannot be marked anymore.
+type keepTraceProcessor struct {
+	events map[string]bool
+
+	mu        sync.Mutex
+	roots     map[trace.TraceID]sdktrace.ReadWriteSpan // open local root per trace
+	lastSweep time.Time
+}
+
+func newKeepTraceProcessor(events []string) *keepTraceProcessor {
+	p := &keepTraceProcessor{events: map[string]bool{}, roots: map[trace.TraceID]sdktrace.ReadWriteSpan{}}
+	for _, event := range events {
+		p.events[event] = true
+	}
+	return p
+}
+
+func (p *keepTraceProcessor) OnStart(_ context.Context, s sdktrace.ReadWriteSpan) {
+	if !isLocalRoot(s.Parent()) {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := s.StartTime()
+	if now.Sub(p.lastSweep) > keepSweepInterval {
+		p.evictOld(now)
+	}
+	if _, ok := p.roots[s.SpanContext().TraceID()]; !ok && len(p.roots) < maxKeepRoots {
+		p.roots[s.SpanContext().TraceID()] = s
+	}
+}
+
+// evictOld forgets roots started more than keepRootMaxAge before now.
+func (p *keepTraceProcessor) evictOld(now time.Time) {
+	for traceID, root := range p.roots {
+		if now.Sub(root.StartTime()) > keepRootMaxAge {
+			delete(p.roots, traceID)
+		}
+	}
+	p.lastSweep = now
+}
+
+func (p *keepTraceProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	traceID := s.SpanContext().TraceID()
+	p.mu.Lock()
+	root := p.roots[traceID]
+	if root != nil && root.SpanContext().SpanID() == s.SpanContext().SpanID() {
+		delete(p.roots, traceID)
+		root = nil
+	}
+	p.mu.Unlock()
+
+	if root != nil && p.keep(s) {
+		root.SetAttributes(samplingKeepKey.Bool(true))
+	}
+}
+
+func (p *keepTraceProcessor) keep(s sdktrace.ReadOnlySpan) bool {
+	for _, attr := range s.Attributes() {
+		if attr.Key == samplingKeepKey && attr.Value.AsBool() {
+			return true
+		}
+	}
+	for _, event := range s.Events() {
+		if p.events[event.Name] {
+			return true
+		}
+	}
+	return false
+}
+
+func (*keepTraceProcessor) Shutdown(context.Context) error   { return nil }
+func (*keepTraceProcessor) ForceFlush(context.Context) error { return nil }
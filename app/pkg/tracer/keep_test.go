@@ -0,0 +1,28 @@
+package tracer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestKeepTraceProcessorForgetsOldRoots(t *testing.T) {
+	keep := newKeepTraceProcessor(nil)
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(keep))
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+	tracer := tp.Tracer("test")
+
+	start := time.Now()
+	tracer.Start(context.Background(), "leaked", trace.WithTimestamp(start))
+	_, span := tracer.Start(context.Background(), "later", trace.WithTimestamp(start.Add(keepRootMaxAge+time.Minute)))
+	defer span.End()
+
+	keep.mu.Lock()
+	defer keep.mu.Unlock()
+	if len(keep.roots) != 1 {
+		t.Errorf("tracking %d roots, want only the recent one", len(keep.roots))
+	}
+}
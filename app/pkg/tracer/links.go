@@ -0,0 +1,43 @@
+package tracer
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// LinksFromTraceparents builds span links for the items of a batch request,
+// one per W3C traceparent value ("00-<32 hex trace id>-<16 hex span id>-<2
+// hex flags>"), e.g. taken from a traceparent field on each imported item.
+// Each link carries the item position as batch.item.index. Empty or invalid
+// values are skipped.
+//
+// Attach the links when starting the span processing the batch:
+//
+//	ctx, span := tracer.StartSpan(ctx, "import", trace.WithLinks(tracer.LinksFromTraceparents(values)...))
+func LinksFromTraceparents(traceparents []string) []trace.Link {
+	links := make([]trace.Link, 0, len(traceparents))
+	for i, traceparent := range traceparents {
+		sc, ok := parseTraceparent(traceparent)
+		if !ok {
+			continue
+		}
+		links = append(links, trace.Link{
+			SpanContext: sc,
+			Attributes:  []attribute.KeyValue{attribute.Int("batch.item.index", i)},
+		})
+	}
+	return links
+}
+
+func parseTraceparent(traceparent string) (trace.SpanContext, bool) {
+	if traceparent == "" {
+		return trace.SpanContext{}, false
+	}
+	carrier := propagation.MapCarrier{"traceparent": traceparent}
+	ctx := propagation.TraceContext{}.Extract(context.Background(), carrier)
+	sc := trace.SpanContextFromContext(ctx)
+	return sc, sc.IsValid()
+}
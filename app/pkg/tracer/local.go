@@ -0,0 +1,134 @@
+package tracer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// localPendingTTL bounds how long spans wait for their local root, which
+// never arrives when it was not sampled.
+const localPendingTTL = time.Minute
+
+// localExporter prints a compact tree per trace once its local root span is
+// exported, e.g.
+//
+//	trace 4bf92f35 GET /orders 12.3ms 4 spans
+//	  ├─ db.query 8.1ms
+//	  │  └─ cache.get 0.4ms ERROR timeout
+//	  └─ render 1.2ms
+//	  orphan queue.publish 0.3ms
+//
+// Spans whose parent is not part of the trace, e.g. because the parent
+// ended after the root, follow the tree as extra roots marked orphan.
+type localExporter struct {
+	out io.Writer
+
+	mu      sync.Mutex
+	pending map[trace.TraceID]*localTrace
+}
+
+type localTrace struct {
+	spans []sdktrace.ReadOnlySpan
+	seen  time.Time
+}
+
+func newLocalExporter() *localExporter {
+	return &localExporter{out: os.Stdout, pending: map[trace.TraceID]*localTrace{}}
+}
+
+func (e *localExporter) ExportSpans(_ context.Context, spans []sdktrace.ReadOnlySpan) error {
+	now := time.Now()
+	var complete [][]sdktrace.ReadOnlySpan
+
+	e.mu.Lock()
+	for _, s := range spans {
+		traceID := s.SpanContext().TraceID()
+		t := e.pending[traceID]
+		if t == nil {
+			t = &localTrace{}
+			e.pending[traceID] = t
+		}
+		t.spans = append(t.spans, s)
+		t.seen = now
+		if isLocalRoot(s.Parent()) {
+			complete = append(complete, t.spans)
+			delete(e.pending, traceID)
+		}
+	}
+	for traceID, t := range e.pending {
+		if now.Sub(t.seen) > localPendingTTL {
+			delete(e.pending, traceID)
+		}
+	}
+	e.mu.Unlock()
+
+	for _, spans := range complete {
+		fmt.Fprint(e.out, formatLocalTrace(spans))
+	}
+	return nil
+}
+
+func (e *localExporter) Shutdown(context.Context) error { return nil }
+
+func formatLocalTrace(spans []sdktrace.ReadOnlySpan) string {
+	children := map[trace.SpanID][]sdktrace.ReadOnlySpan{}
+	ids := map[trace.SpanID]bool{}
+	for _, s := range spans {
+		ids[s.SpanContext().SpanID()] = true
+	}
+	var roots []sdktrace.ReadOnlySpan
+	for _, s := range spans {
+		if parent := s.Parent(); parent.IsValid() && ids[parent.SpanID()] {
+			children[parent.SpanID()] = append(children[parent.SpanID()], s)
+		} else {
+			roots = append(roots, s)
+		}
+	}
+	for _, c := range children {
+		sort.Slice(c, func(i, j int) bool { return c[i].StartTime().Before(c[j].StartTime()) })
+	}
+
+	var b strings.Builder
+	// The last span exported is the local root, the others are early orphans.
+	root := roots[len(roots)-1]
+	fmt.Fprintf(&b, "trace %s %s %d spans\n", root.SpanContext().TraceID().String()[:8], localSpanSummary(root), len(spans))
+	var walk func(s sdktrace.ReadOnlySpan, indent string)
+	walk = func(s sdktrace.ReadOnlySpan, indent string) {
+		c := children[s.SpanContext().SpanID()]
+		for i, child := range c {
+			branch, next := "├─ ", "│  "
+			if i == len(c)-1 {
+				branch, next = "└─ ", "   "
+			}
+			fmt.Fprintf(&b, "%s%s%s\n", indent, branch, localSpanSummary(child))
+			walk(child, indent+next)
+		}
+	}
+	walk(root, "  ")
+	orphans := roots[:len(roots)-1]
+	sort.Slice(orphans, func(i, j int) bool { return orphans[i].StartTime().Before(orphans[j].StartTime()) })
+	for _, orphan := range orphans {
+		fmt.Fprintf(&b, "  orphan %s\n", localSpanSummary(orphan))
+		walk(orphan, "    ")
+	}
+	return b.String()
+}
+
+func localSpanSummary(s sdktrace.ReadOnlySpan) string {
+	ms := float64(s.EndTime().Sub(s.StartTime())) / float64(time.Millisecond)
+	summary := fmt.Sprintf("%s %.1fms", s.Name(), ms)
+	if status := s.Status(); status.Code == codes.Error {
+		summary += " ERROR " + status.Description
+	}
+	return summary
+}
@@ -0,0 +1,42 @@
+package tracer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestFormatLocalTracePrintsOrphans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+	tracer := tp.Tracer("test")
+
+	ctx, root := tracer.Start(context.Background(), "GET /orders")
+	_, child := tracer.Start(ctx, "db.query")
+	child.End()
+	lostCtx, lost := tracer.Start(ctx, "queue.publish")
+	_, orphan := tracer.Start(lostCtx, "queue.encode")
+	orphan.End()
+	root.End()
+	lost.End()
+
+	// queue.publish ends after the root, so the local exporter never sees
+	// it with the rest of the trace.
+	var spans []sdktrace.ReadOnlySpan
+	for _, s := range recorder.Ended() {
+		if s.Name() != "queue.publish" {
+			spans = append(spans, s)
+		}
+	}
+
+	out := formatLocalTrace(spans)
+	for _, want := range []string{"GET /orders", "└─ db.query", "  orphan queue.encode"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output is missing %q:\n%s", want, out)
+		}
+	}
+}
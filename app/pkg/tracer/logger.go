@@ -0,0 +1,75 @@
+package tracer
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Logger receives the tracer's diagnostic output.
+type Logger interface {
+	Printf(format string, args ...any)
+}
+
+type stdoutLogger struct{}
+
+func (stdoutLogger) Printf(format string, args ...any) {
+	fmt.Printf(format+"\n", args...)
+}
+
+func loggerOrDefault(logger Logger) Logger {
+	if logger == nil {
+		return stdoutLogger{}
+	}
+	return logger
+}
+
+// installedLogger is the logger of the last InitTracer, for package
+// functions that take no Config.
+var installedLogger atomic.Pointer[Logger]
+
+func packageLogger() Logger {
+	if logger := installedLogger.Load(); logger != nil {
+		return *logger
+	}
+	return stdoutLogger{}
+}
+
+// sdkErrorHandler routes OpenTelemetry SDK internal errors, such as failed
+// exports, to the logger and optionally counts them.
+type sdkErrorHandler struct {
+	logger Logger
+	count  bool
+}
+
+func (h sdkErrorHandler) Handle(err error) {
+	h.logger.Printf("OpenTelemetry SDK error: %v", err)
+	if !h.count {
+		return
+	}
+	counter, cerr := otel.Meter(instrumentationName).Int64Counter("otel.sdk.errors")
+	if cerr == nil {
+		counter.Add(context.Background(), 1)
+	}
+}
+
+// spanLogProcessor logs the start and end of every span.
+type spanLogProcessor struct {
+	logger Logger
+}
+
+func (p spanLogProcessor) OnStart(_ context.Context, s sdktrace.ReadWriteSpan) {
+	p.logger.Printf("span start: %s trace_id=%s span_id=%s", s.Name(), s.SpanContext().TraceID(), s.SpanContext().SpanID())
+}
+
+func (p spanLogProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	p.logger.Printf("span end: %s trace_id=%s span_id=%s duration=%s sampled=%t",
+		s.Name(), s.SpanContext().TraceID(), s.SpanContext().SpanID(),
+		s.EndTime().Sub(s.StartTime()), s.SpanContext().IsSampled())
+}
+
+func (spanLogProcessor) Shutdown(context.Context) error   { return nil }
+func (spanLogProcessor) ForceFlush(context.Context) error { return nil }
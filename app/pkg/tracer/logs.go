@@ -0,0 +1,217 @@
+package tracer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/global"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+)
+
+// InitLoggerProvider installs a global logger provider exporting over OTLP to
+// the same backend as config.TracingTool (OTLP or SIGNOZ) and labelled with
+// the tracer resource. Use NewSlogHandler to feed slog records into it.
+func InitLoggerProvider(ctx context.Context, serviceName, environment, moduleName string, config Config) (*sdklog.LoggerProvider, error) {
+	exporter, err := newLogExporter(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := BuildResource(ctx, serviceName, environment, moduleName, config)
+	if err != nil {
+		loggerOrDefault(config.Logger).Printf("Failed to create logger resource: %v", err)
+		return nil, err
+	}
+
+	lp := sdklog.NewLoggerProvider(
+		sdklog.WithResource(res),
+		sdklog.WithProcessor(sdklog.NewBatchProcessor(exporter)),
+	)
+	global.SetLoggerProvider(lp)
+	return lp, nil
+}
+
+func newLogExporter(ctx context.Context, config Config) (sdklog.Exporter, error) {
+	config = withDiscoveredEndpoint(config)
+	switch {
+	case hasTool(config, ToolSigNoz):
+		endpoint, insecure, headers, err := signozTarget(config)
+		if err != nil {
+			return nil, err
+		}
+		config.OTLPEndpoint, config.OTLPInsecure, config.OTLPHeaders = endpoint, insecure, headers
+		return newOTLPLogExporter(ctx, config, otlpProtocolGRPC)
+	case hasTool(config, ToolOTLP, ToolOTLPGRPC, ToolOTLPHTTP):
+		return newOTLPLogExporter(ctx, config, otlpProtocol(config))
+	}
+	return nil, errors.New("logs are not supported for tracing tool " + config.TracingTool)
+}
+
+// newOTLPLogExporter creates the OTLP log exporter for protocol, set up like
+// the trace exporter of the same protocol.
+func newOTLPLogExporter(ctx context.Context, config Config, protocol string) (sdklog.Exporter, error) {
+	if protocol == otlpProtocolHTTP {
+		return newOTLPLogHTTPExporter(ctx, config)
+	}
+	target, dialOpts, insecure, err := otlpGRPCTarget(config.OTLPEndpoint, config.OTLPInsecure, otlpKeepalive(config))
+	if err != nil {
+		return nil, err
+	}
+	opts := []otlploggrpc.Option{otlploggrpc.WithDialOption(dialOpts...)}
+	if target != "" {
+		opts = append(opts, otlploggrpc.WithEndpoint(target))
+	}
+	if insecure {
+		opts = append(opts, otlploggrpc.WithInsecure())
+	}
+	if len(config.OTLPHeaders) > 0 {
+		opts = append(opts, otlploggrpc.WithHeaders(config.OTLPHeaders))
+	}
+	if config.OTLPCompression == otlpCompressionGzip {
+		opts = append(opts, otlploggrpc.WithCompressor(otlpCompressionGzip))
+	}
+	return otlploggrpc.New(ctx, opts...)
+}
+
+func newOTLPLogHTTPExporter(ctx context.Context, config Config) (sdklog.Exporter, error) {
+	var opts []otlploghttp.Option
+	if endpoint := config.OTLPEndpoint; strings.Contains(endpoint, "://") {
+		opts = append(opts, otlploghttp.WithEndpointURL(endpoint))
+	} else if endpoint != "" {
+		opts = append(opts, otlploghttp.WithEndpoint(endpoint))
+	}
+	if config.OTLPInsecure {
+		opts = append(opts, otlploghttp.WithInsecure())
+	}
+	if len(config.OTLPHeaders) > 0 {
+		opts = append(opts, otlploghttp.WithHeaders(config.OTLPHeaders))
+	}
+	if config.OTLPCompression == otlpCompressionGzip {
+		opts = append(opts, otlploghttp.WithCompression(otlploghttp.GzipCompression))
+	}
+	proxy, err := otlpProxy(config)
+	if err != nil {
+		return nil, err
+	}
+	if proxy != nil {
+		opts = append(opts, otlploghttp.WithProxy(proxy))
+	}
+	return otlploghttp.New(ctx, opts...)
+}
+
+// NewSlogHandler returns an slog.Handler emitting records at or above level to
+// the global logger provider. Records logged with a context carrying a span
+// are correlated with its trace and span IDs.
+func NewSlogHandler(level slog.Leveler) slog.Handler {
+	if level == nil {
+		level = slog.LevelInfo
+	}
+	return &slogHandler{logger: global.Logger(instrumentationName), level: level}
+}
+
+type slogHandler struct {
+	logger log.Logger
+	level  slog.Leveler
+	attrs  []log.KeyValue
+	group  string
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	var record log.Record
+	record.SetTimestamp(r.Time)
+	record.SetObservedTimestamp(time.Now())
+	record.SetSeverity(slogSeverity(r.Level))
+	record.SetSeverityText(r.Level.String())
+	record.SetBody(log.StringValue(r.Message))
+	record.AddAttributes(h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		if kv, ok := slogKeyValue(h.group, a); ok {
+			record.AddAttributes(kv)
+		}
+		return true
+	})
+	// The SDK reads the span context from ctx.
+	h.logger.Emit(ctx, record)
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append([]log.KeyValue(nil), h.attrs...)
+	for _, a := range attrs {
+		if kv, ok := slogKeyValue(h.group, a); ok {
+			clone.attrs = append(clone.attrs, kv)
+		}
+	}
+	return &clone
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.group = h.group + name + "."
+	return &clone
+}
+
+// slogSeverity maps slog levels onto OTel severities: debug, info, warn and
+// error are 4 apart in both.
+func slogSeverity(level slog.Level) log.Severity {
+	severity := log.Severity(int(level) + int(log.SeverityInfo))
+	if severity < log.SeverityTrace1 {
+		return log.SeverityTrace1
+	}
+	if severity > log.SeverityFatal4 {
+		return log.SeverityFatal4
+	}
+	return severity
+}
+
+func slogKeyValue(prefix string, a slog.Attr) (log.KeyValue, bool) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return log.KeyValue{}, false
+	}
+	return log.KeyValue{Key: prefix + a.Key, Value: slogValue(a.Value)}, true
+}
+
+func slogValue(v slog.Value) log.Value {
+	switch v.Kind() {
+	case slog.KindString:
+		return log.StringValue(v.String())
+	case slog.KindInt64:
+		return log.Int64Value(v.Int64())
+	case slog.KindUint64:
+		return log.Int64Value(int64(v.Uint64()))
+	case slog.KindFloat64:
+		return log.Float64Value(v.Float64())
+	case slog.KindBool:
+		return log.BoolValue(v.Bool())
+	case slog.KindDuration:
+		return log.Int64Value(v.Duration().Nanoseconds())
+	case slog.KindTime:
+		return log.StringValue(v.Time().Format(time.RFC3339Nano))
+	case slog.KindGroup:
+		group := v.Group()
+		kvs := make([]log.KeyValue, 0, len(group))
+		for _, a := range group {
+			if kv, ok := slogKeyValue("", a); ok {
+				kvs = append(kvs, kv)
+			}
+		}
+		return log.MapValue(kvs...)
+	}
+	return log.StringValue(fmt.Sprint(v.Any()))
+}
@@ -0,0 +1,146 @@
+package tracer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/exemplar"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var valueHistograms sync.Map // name -> metric.Float64Histogram
+
+// RecordValue annotates the current span with name=value and records value to
+// a histogram called name on the global meter provider. attrs are used as the
+// metric dimensions. The metric side is a no-op until a meter provider is set.
+func RecordValue(ctx context.Context, name string, value float64, attrs ...attribute.KeyValue) {
+	span := trace.SpanFromContext(ctx)
+	if span.IsRecording() {
+		span.SetAttributes(attribute.Float64(name, value))
+	}
+
+	histogram, err := valueHistogram(name)
+	if err != nil {
+		packageLogger().Printf("Failed to create histogram for %s: %v", name, err)
+		return
+	}
+	histogram.Record(ctx, value, metric.WithAttributes(attrs...))
+}
+
+func valueHistogram(name string) (metric.Float64Histogram, error) {
+	if h, ok := valueHistograms.Load(name); ok {
+		return h.(metric.Float64Histogram), nil
+	}
+	// Instruments from the global meter follow the provider once it is
+	// installed, so caching them here is safe.
+	h, err := otel.Meter(instrumentationName).Float64Histogram(name)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := valueHistograms.LoadOrStore(name, h)
+	return actual.(metric.Float64Histogram), nil
+}
+
+// InitMeterProvider installs a global meter provider exporting to the same
+// backend as config.TracingTool (OTLP, SIGNOZ or STDOUT) and labelled with the
+// tracer resource. Exemplars are taken from sampled spans in the recording
+// context, so metric points link back to an example trace.
+func InitMeterProvider(ctx context.Context, serviceName, environment, moduleName string, config Config) (*sdkmetric.MeterProvider, error) {
+	exporter, err := newMetricExporter(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := BuildResource(ctx, serviceName, environment, moduleName, config)
+	if err != nil {
+		loggerOrDefault(config.Logger).Printf("Failed to create meter resource: %v", err)
+		return nil, err
+	}
+
+	mp := sdkmetric.NewMeterProvider(
+		sdkmetric.WithResource(res),
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter)),
+		sdkmetric.WithExemplarFilter(exemplar.TraceBasedFilter),
+	)
+	otel.SetMeterProvider(mp)
+	return mp, nil
+}
+
+func newMetricExporter(ctx context.Context, config Config) (sdkmetric.Exporter, error) {
+	config = withDiscoveredEndpoint(config)
+	switch {
+	case hasTool(config, ToolStdout):
+		return stdoutmetric.New()
+	case hasTool(config, ToolSigNoz):
+		endpoint, insecure, headers, err := signozTarget(config)
+		if err != nil {
+			return nil, err
+		}
+		config.OTLPEndpoint, config.OTLPInsecure, config.OTLPHeaders = endpoint, insecure, headers
+		return newOTLPMetricExporter(ctx, config, otlpProtocolGRPC)
+	case hasTool(config, ToolOTLP, ToolOTLPGRPC, ToolOTLPHTTP):
+		return newOTLPMetricExporter(ctx, config, otlpProtocol(config))
+	}
+	return nil, errors.New("metrics are not supported for tracing tool " + config.TracingTool)
+}
+
+// newOTLPMetricExporter creates the OTLP metric exporter for protocol, set up
+// like the trace exporter of the same protocol.
+func newOTLPMetricExporter(ctx context.Context, config Config, protocol string) (sdkmetric.Exporter, error) {
+	if protocol == otlpProtocolHTTP {
+		return newOTLPMetricHTTPExporter(ctx, config)
+	}
+	target, dialOpts, insecure, err := otlpGRPCTarget(config.OTLPEndpoint, config.OTLPInsecure, otlpKeepalive(config))
+	if err != nil {
+		return nil, err
+	}
+	opts := []otlpmetricgrpc.Option{otlpmetricgrpc.WithDialOption(dialOpts...)}
+	if target != "" {
+		opts = append(opts, otlpmetricgrpc.WithEndpoint(target))
+	}
+	if insecure {
+		opts = append(opts, otlpmetricgrpc.WithInsecure())
+	}
+	if len(config.OTLPHeaders) > 0 {
+		opts = append(opts, otlpmetricgrpc.WithHeaders(config.OTLPHeaders))
+	}
+	if config.OTLPCompression == otlpCompressionGzip {
+		opts = append(opts, otlpmetricgrpc.WithCompressor(otlpCompressionGzip))
+	}
+	return otlpmetricgrpc.New(ctx, opts...)
+}
+
+func newOTLPMetricHTTPExporter(ctx context.Context, config Config) (sdkmetric.Exporter, error) {
+	var opts []otlpmetrichttp.Option
+	if endpoint := config.OTLPEndpoint; strings.Contains(endpoint, "://") {
+		opts = append(opts, otlpmetrichttp.WithEndpointURL(endpoint))
+	} else if endpoint != "" {
+		opts = append(opts, otlpmetrichttp.WithEndpoint(endpoint))
+	}
+	if config.OTLPInsecure {
+		opts = append(opts, otlpmetrichttp.WithInsecure())
+	}
+	if len(config.OTLPHeaders) > 0 {
+		opts = append(opts, otlpmetrichttp.WithHeaders(config.OTLPHeaders))
+	}
+	if config.OTLPCompression == otlpCompressionGzip {
+		opts = append(opts, otlpmetrichttp.WithCompression(otlpmetrichttp.GzipCompression))
+	}
+	proxy, err := otlpProxy(config)
+	if err != nil {
+		return nil, err
+	}
+	if proxy != nil {
+		opts = append(opts, otlpmetrichttp.WithProxy(proxy))
+	}
+	return otlpmetrichttp.New(ctx, opts...)
+}
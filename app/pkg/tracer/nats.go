@@ -0,0 +1,130 @@
+package tracer
+
+import (
+	"context"
+
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const natsMessagingSystem = "nats"
+
+// NATSHeaderCarrier adapts nats.Header to propagation.TextMapCarrier.
+// NATS headers are case-sensitive, so keys are used as given.
+type NATSHeaderCarrier nats.Header
+
+var _ propagation.TextMapCarrier = NATSHeaderCarrier{}
+
+func (c NATSHeaderCarrier) Get(key string) string {
+	return nats.Header(c).Get(key)
+}
+
+func (c NATSHeaderCarrier) Set(key, value string) {
+	nats.Header(c).Set(key, value)
+}
+
+func (c NATSHeaderCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// InjectNATS writes the trace context of ctx into the message headers using
+// the global propagator. Headers are created when the message has none.
+func InjectNATS(ctx context.Context, msg *nats.Msg) {
+	if msg == nil {
+		return
+	}
+	if msg.Header == nil {
+		msg.Header = nats.Header{}
+	}
+	otel.GetTextMapPropagator().Inject(ctx, NATSHeaderCarrier(msg.Header))
+}
+
+// ExtractNATS returns ctx enriched with the trace context carried by the
+// message headers. Messages without headers return ctx unchanged.
+func ExtractNATS(ctx context.Context, msg *nats.Msg) context.Context {
+	if msg == nil || len(msg.Header) == 0 {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, NATSHeaderCarrier(msg.Header))
+}
+
+// StartNATSPublishSpan starts a producer span for msg and injects its context
+// into the message headers. Call it right before publishing and end the span
+// once the publish returns. A nil msg gets a non-recording span.
+func StartNATSPublishSpan(ctx context.Context, msg *nats.Msg) (context.Context, trace.Span) {
+	if msg == nil {
+		return noop.NewTracerProvider().Tracer(instrumentationName).Start(ctx, "nats send")
+	}
+	ctx, span := packageTracer().Start(ctx, msg.Subject+" send",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKey.String(natsMessagingSystem),
+			semconv.MessagingOperationTypeSend,
+			semconv.MessagingDestinationName(msg.Subject),
+			semconv.MessagingMessageBodySize(len(msg.Data)),
+		),
+	)
+	InjectNATS(ctx, msg)
+	return ctx, span
+}
+
+// StartNATSConsumeSpan extracts the upstream trace context from msg and starts
+// a consumer span for processing it. A nil msg gets a non-recording span.
+func StartNATSConsumeSpan(ctx context.Context, msg *nats.Msg) (context.Context, trace.Span) {
+	if msg == nil {
+		return noop.NewTracerProvider().Tracer(instrumentationName).Start(ctx, "nats process")
+	}
+	ctx = ExtractNATS(ctx, msg)
+	return packageTracer().Start(ctx, msg.Subject+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKey.String(natsMessagingSystem),
+			semconv.MessagingOperationTypeProcess,
+			semconv.MessagingDestinationName(msg.Subject),
+			semconv.MessagingMessageBodySize(len(msg.Data)),
+		),
+	)
+}
+
+// PublishNATS publishes msg on nc inside a producer span carrying the trace
+// context in the message headers. Publish errors are recorded on the span.
+// A nil nc or msg is rejected before any span is started.
+func PublishNATS(ctx context.Context, nc *nats.Conn, msg *nats.Msg) error {
+	if nc == nil {
+		return nats.ErrInvalidConnection
+	}
+	if msg == nil {
+		return nats.ErrInvalidMsg
+	}
+	_, span := StartNATSPublishSpan(ctx, msg)
+	defer span.End()
+
+	if err := nc.PublishMsg(msg); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	return nil
+}
+
+// NATSHandler wraps handler so every delivered message is processed inside a
+// consumer span continuing the publisher's trace. A nil handler only
+// records the spans.
+func NATSHandler(handler func(ctx context.Context, msg *nats.Msg)) nats.MsgHandler {
+	return func(msg *nats.Msg) {
+		ctx, span := StartNATSConsumeSpan(context.Background(), msg)
+		defer span.End()
+		if handler != nil {
+			handler(ctx, msg)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package tracer
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+type noTraceKey struct{}
+
+// withNoTrace marks ctx so that no span is recorded under it, by any
+// tracer, and no trace context or baggage is injected into outgoing
+// requests.
+func withNoTrace(ctx context.Context) context.Context {
+	ctx = SuppressTracing(WithDoNotTrace(ctx))
+	return context.WithValue(ctx, noTraceKey{}, true)
+}
+
+func noTrace(ctx context.Context) bool {
+	v, _ := ctx.Value(noTraceKey{}).(bool)
+	return v
+}
+
+// stripTraceHeaders removes the incoming trace context and baggage so
+// nothing further down the chain picks them up.
+func stripTraceHeaders(r *http.Request) {
+	for _, field := range newPropagator().Fields() {
+		r.Header.Del(field)
+	}
+}
+
+// noTraceMiddleware marks requests carrying header with withNoTrace. It runs
+// before otelgin, which skips them through noTraceFilter.
+func noTraceMiddleware(header string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader(header) != "" {
+			stripTraceHeaders(c.Request)
+			c.Request = c.Request.WithContext(withNoTrace(c.Request.Context()))
+		}
+		c.Next()
+	}
+}
+
+func noTraceFilter(c *gin.Context) bool {
+	return !noTrace(c.Request.Context())
+}
+
+// NoTraceMiddleware is Middleware, except that requests carrying header
+// (e.g. "X-No-Trace") go straight to next: no span is created for them and
+// no trace context is propagated downstream. It is the net/http counterpart
+// of Config.NoTraceHeader, which must be set as well for spans started while
+// handling such requests to be dropped.
+func NoTraceMiddleware(header string, next http.Handler) http.Handler {
+	traced := Middleware(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(header) == "" {
+			traced.ServeHTTP(w, r)
+			return
+		}
+		stripTraceHeaders(r)
+		next.ServeHTTP(w, r.WithContext(withNoTrace(r.Context())))
+	})
+}
+
+// noTracePropagator does not inject anything under a withNoTrace context.
+type noTracePropagator struct {
+	propagation.TextMapPropagator
+}
+
+func (p noTracePropagator) Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	if noTrace(ctx) {
+		return
+	}
+	p.TextMapPropagator.Inject(ctx, carrier)
+}
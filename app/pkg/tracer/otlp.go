@@ -0,0 +1,281 @@
+package tracer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+const (
+	otlpProtocolGRPC = "grpc"
+	otlpProtocolHTTP = "http"
+	otlpHTTPPort     = "4318"
+
+	otlpCompressionGzip = "gzip"
+
+	signozSelfHostedEndpoint  = "localhost:4317"
+	signozCloudEndpointFormat = "ingest.%s.signoz.cloud:443"
+	signozIngestionKeyHeader  = "signoz-ingestion-key"
+)
+
+// newOTLPExporter creates the OTLP exporter for the protocol picked by
+// otlpProtocol.
+func newOTLPExporter(ctx context.Context, config Config) (*otlptrace.Exporter, error) {
+	protocol := otlpProtocol(config)
+	loggerOrDefault(config.Logger).Printf("Using OTLP protocol %s for endpoint %s", protocol, config.OTLPEndpoint)
+	if protocol == otlpProtocolHTTP {
+		return newOTLPHTTPExporter(ctx, config)
+	}
+	return newOTLPGRPCExporter(ctx, config.OTLPEndpoint, config.OTLPInsecure, config.OTLPHeaders, config.OTLPCompression, otlpKeepalive(config))
+}
+
+// otlpProtocol returns the protocol for the OTLP tool. OTLP_GRPC and OTLP_HTTP
+// are used as given; plain OTLP picks HTTP when the endpoint port is 4318 and
+// gRPC otherwise.
+func otlpProtocol(config Config) string {
+	switch {
+	case hasTool(config, ToolOTLPHTTP):
+		return otlpProtocolHTTP
+	case hasTool(config, ToolOTLPGRPC):
+		return otlpProtocolGRPC
+	case endpointPort(config.OTLPEndpoint) == otlpHTTPPort:
+		return otlpProtocolHTTP
+	}
+	return otlpProtocolGRPC
+}
+
+func endpointPort(endpoint string) string {
+	if strings.Contains(endpoint, "://") {
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return ""
+		}
+		return u.Port()
+	}
+	_, port, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return ""
+	}
+	return port
+}
+
+// newOTLPHTTPExporter creates an OTLP HTTP trace exporter. The endpoint is
+// either host:port or a full URL. Requests go through config.ProxyURL when
+// set, and otherwise through the HTTP_PROXY/HTTPS_PROXY/NO_PROXY
+// environment like any net/http client.
+func newOTLPHTTPExporter(ctx context.Context, config Config) (*otlptrace.Exporter, error) {
+	var opts []otlptracehttp.Option
+	endpoint := config.OTLPEndpoint
+	if strings.Contains(endpoint, "://") {
+		opts = append(opts, otlptracehttp.WithEndpointURL(endpoint))
+	} else if endpoint != "" {
+		opts = append(opts, otlptracehttp.WithEndpoint(endpoint))
+	}
+	if config.OTLPInsecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	if len(config.OTLPHeaders) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(config.OTLPHeaders))
+	}
+	if config.OTLPCompression == otlpCompressionGzip {
+		opts = append(opts, otlptracehttp.WithCompression(otlptracehttp.GzipCompression))
+	}
+	proxy, err := otlpProxy(config)
+	if err != nil {
+		return nil, err
+	}
+	if proxy != nil {
+		opts = append(opts, otlptracehttp.WithProxy(proxy))
+	}
+	return otlptracehttp.New(ctx, opts...)
+}
+
+// OTLPKeepalive configures gRPC keepalive pings on the OTLP connection, see
+// keepalive.ClientParameters. Pings more frequent than the server allows
+// make the server close the connection, so the collector needs a matching
+// keepalive.EnforcementPolicy: MinTime no greater than Time, and
+// PermitWithoutStream true when pinging idle connections. gRPC servers
+// default to MinTime 5m and reject pings without an active RPC.
+type OTLPKeepalive struct {
+	Time                time.Duration
+	Timeout             time.Duration
+	PermitWithoutStream bool
+}
+
+// defaultOTLPKeepalive pings idle connections every 2 minutes, below the
+// roughly 4 minute idle timeout of common NATs and cloud load balancers. The
+// collector must allow it with EnforcementPolicy{MinTime: 2 * time.Minute,
+// PermitWithoutStream: true} or lower; the OpenTelemetry Collector's
+// keepalive.enforcement_policy sets the same.
+var defaultOTLPKeepalive = OTLPKeepalive{Time: 2 * time.Minute, Timeout: 20 * time.Second, PermitWithoutStream: true}
+
+func otlpKeepalive(config Config) OTLPKeepalive {
+	if config.OTLPKeepalive == nil {
+		return defaultOTLPKeepalive
+	}
+	return *config.OTLPKeepalive
+}
+
+// newOTLPGRPCExporter creates an OTLP gRPC trace exporter. Endpoints of the
+// form unix:///path/to/socket are dialed over a Unix domain socket.
+func newOTLPGRPCExporter(ctx context.Context, endpoint string, insecure bool, headers map[string]string, compression string, ka OTLPKeepalive) (*otlptrace.Exporter, error) {
+	target, dialOpts, insecure, err := otlpGRPCTarget(endpoint, insecure, ka)
+	if err != nil {
+		return nil, err
+	}
+	opts := []otlptracegrpc.Option{otlptracegrpc.WithDialOption(dialOpts...)}
+	if target != "" {
+		opts = append(opts, otlptracegrpc.WithEndpoint(target))
+	}
+	if insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	if len(headers) > 0 {
+		opts = append(opts, otlptracegrpc.WithHeaders(headers))
+	}
+	if compression == otlpCompressionGzip {
+		opts = append(opts, otlptracegrpc.WithCompressor(otlpCompressionGzip))
+	}
+	return otlptracegrpc.New(ctx, opts...)
+}
+
+// otlpGRPCTarget resolves endpoint for the OTLP gRPC exporters of every
+// signal: the target for WithEndpoint (empty keeps the SDK default and
+// OTEL_EXPORTER_OTLP_ENDPOINT), the keepalive and Unix socket dial options,
+// and whether to dial insecurely.
+func otlpGRPCTarget(endpoint string, insecure bool, ka OTLPKeepalive) (string, []grpc.DialOption, bool, error) {
+	dialOpts := []grpc.DialOption{grpc.WithKeepaliveParams(keepalive.ClientParameters{
+		Time:                ka.Time,
+		Timeout:             ka.Timeout,
+		PermitWithoutStream: ka.PermitWithoutStream,
+	})}
+	path, ok, err := unixSocketPath(endpoint)
+	if !ok {
+		return endpoint, dialOpts, insecure, nil
+	}
+	if err != nil {
+		return "", nil, false, err
+	}
+	if err := checkUnixSocket(path); err != nil {
+		return "", nil, false, err
+	}
+	return "passthrough:///" + path, append(dialOpts, grpc.WithContextDialer(dialUnix)), true, nil
+}
+
+// otlpProxy returns the proxy function for config.ProxyURL, or nil when the
+// environment should be used.
+func otlpProxy(config Config) (func(*http.Request) (*url.URL, error), error) {
+	if config.ProxyURL == "" {
+		return nil, nil
+	}
+	proxy, err := url.Parse(config.ProxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	// Credentials in the URL are sent as Proxy-Authorization.
+	return http.ProxyURL(proxy), nil
+}
+
+// unixSocketPath returns the socket path of unix:/path and unix:///path
+// endpoints. ok reports whether endpoint uses the unix scheme at all; relative
+// paths are rejected rather than resolved against the working directory.
+func unixSocketPath(endpoint string) (path string, ok bool, err error) {
+	if !strings.HasPrefix(endpoint, "unix:") {
+		return "", false, nil
+	}
+	path = strings.TrimPrefix(endpoint, "unix:")
+	if strings.HasPrefix(path, "//") {
+		path = path[2:]
+	}
+	if !filepath.IsAbs(path) {
+		return "", true, fmt.Errorf("otlp unix socket %q: path must be absolute, e.g. unix:///var/run/otel.sock", endpoint)
+	}
+	return path, true, nil
+}
+
+func checkUnixSocket(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("otlp unix socket %s: %w", path, err)
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("otlp unix socket %s: not a socket", path)
+	}
+	return nil
+}
+
+func dialUnix(ctx context.Context, path string) (net.Conn, error) {
+	var d net.Dialer
+	return d.DialContext(ctx, "unix", path)
+}
+
+// newSigNozExporter configures the OTLP gRPC exporter with SigNoz defaults.
+func newSigNozExporter(ctx context.Context, config Config) (*otlptrace.Exporter, error) {
+	endpoint, insecure, headers, err := signozTarget(config)
+	if err != nil {
+		return nil, err
+	}
+	return newOTLPGRPCExporter(ctx, endpoint, insecure, headers, config.OTLPCompression, otlpKeepalive(config))
+}
+
+// signozTarget resolves the OTLP endpoint for SigNoz. Self-hosted installs
+// listen on plain gRPC port 4317, SigNoz Cloud needs the regional ingest
+// endpoint and the ingestion key header.
+func signozTarget(config Config) (endpoint string, insecure bool, headers map[string]string, err error) {
+	if !config.SigNozCloud {
+		if config.OTLPEndpoint == "" {
+			return signozSelfHostedEndpoint, true, config.OTLPHeaders, nil
+		}
+		return config.OTLPEndpoint, config.OTLPInsecure, config.OTLPHeaders, nil
+	}
+
+	if config.SigNozIngestionKey == "" {
+		return "", false, nil, errors.New("signoz ingestion key not configured")
+	}
+	endpoint = config.OTLPEndpoint
+	if endpoint == "" {
+		if config.SigNozRegion == "" {
+			return "", false, nil, errors.New("signoz region not configured")
+		}
+		endpoint = fmt.Sprintf(signozCloudEndpointFormat, config.SigNozRegion)
+	}
+
+	headers = make(map[string]string, len(config.OTLPHeaders)+1)
+	for k, v := range config.OTLPHeaders {
+		headers[k] = v
+	}
+	headers[signozIngestionKeyHeader] = config.SigNozIngestionKey
+	return endpoint, false, headers, nil
+}
+
+// withDiscoveredEndpoint resolves config.OTLPEndpointSRV and uses the first
+// target as OTLPEndpoint. net.LookupSRV already orders targets by priority
+// and weight. The configured OTLPEndpoint is kept when the lookup fails.
+func withDiscoveredEndpoint(config Config) Config {
+	if config.OTLPEndpointSRV == "" {
+		return config
+	}
+	_, addrs, err := net.LookupSRV("", "", config.OTLPEndpointSRV)
+	if err != nil || len(addrs) == 0 {
+		loggerOrDefault(config.Logger).Printf("Failed to resolve OTLP SRV record, using OTLPEndpoint %s: %v", config.OTLPEndpoint, err)
+		return config
+	}
+	host := strings.TrimSuffix(addrs[0].Target, ".")
+	config.OTLPEndpoint = net.JoinHostPort(host, strconv.Itoa(int(addrs[0].Port)))
+	loggerOrDefault(config.Logger).Printf("Resolved OTLP endpoint from SRV record: %s", config.OTLPEndpoint)
+	return config
+}
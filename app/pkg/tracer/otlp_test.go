@@ -0,0 +1,53 @@
+package tracer
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+)
+
+func TestUnixSocketPath(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     string
+		ok       bool
+		wantErr  bool
+	}{
+		{endpoint: "localhost:4317"},
+		{endpoint: "unix:///var/run/otel.sock", want: "/var/run/otel.sock", ok: true},
+		{endpoint: "unix:/var/run/otel.sock", want: "/var/run/otel.sock", ok: true},
+		{endpoint: "unix:otel.sock", ok: true, wantErr: true},
+		{endpoint: "unix://run/otel.sock", ok: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		path, ok, err := unixSocketPath(tt.endpoint)
+		if path != tt.want || ok != tt.ok || (err != nil) != tt.wantErr {
+			t.Errorf("unixSocketPath(%q) = %q, %t, %v; want %q, %t, error %t", tt.endpoint, path, ok, err, tt.want, tt.ok, tt.wantErr)
+		}
+	}
+}
+
+func TestNewLogExporterProtocol(t *testing.T) {
+	tests := []struct {
+		config Config
+		http   bool
+	}{
+		{config: Config{TracingTool: "OTLP", OTLPEndpoint: "collector:4317"}},
+		{config: Config{TracingTool: "OTLP", OTLPEndpoint: "collector:4318"}, http: true},
+		{config: Config{TracingTool: "OTLP", OTLPEndpoint: "http://collector:4318"}, http: true},
+		{config: Config{TracingTool: "OTLP_HTTP"}, http: true},
+		{config: Config{TracingTool: "OTLP_GRPC", OTLPEndpoint: "collector:4318"}},
+	}
+	for _, tt := range tests {
+		exporter, err := newLogExporter(context.Background(), tt.config)
+		if err != nil {
+			t.Fatalf("newLogExporter(%s %s): %v", tt.config.TracingTool, tt.config.OTLPEndpoint, err)
+		}
+		_, isHTTP := exporter.(*otlploghttp.Exporter)
+		if isHTTP != tt.http {
+			t.Errorf("newLogExporter(%s %s) = %T, want http %t", tt.config.TracingTool, tt.config.OTLPEndpoint, exporter, tt.http)
+		}
+		_ = exporter.Shutdown(context.Background())
+	}
+}
@@ -0,0 +1,339 @@
+package tracer
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// CancelledSpanPolicy controls what happens to spans started under a context
+// that is already cancelled.
+type CancelledSpanPolicy string
+
+const (
+	// CancelledSpanKeep records those spans like any other. This is the default.
+	CancelledSpanKeep CancelledSpanPolicy = ""
+	// CancelledSpanTag marks them with cancelled=true and an error status.
+	CancelledSpanTag CancelledSpanPolicy = "TAG"
+	// CancelledSpanDrop does not record them at all.
+	CancelledSpanDrop CancelledSpanPolicy = "DROP"
+)
+
+// cancelledSpanProcessor tags spans whose parent context was cancelled
+// before they started.
+type cancelledSpanProcessor struct{}
+
+func (cancelledSpanProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	if err := parent.Err(); err != nil {
+		s.SetAttributes(attribute.Bool("cancelled", true))
+		s.SetStatus(codes.Error, err.Error())
+	}
+}
+
+func (cancelledSpanProcessor) OnEnd(sdktrace.ReadOnlySpan)      {}
+func (cancelledSpanProcessor) Shutdown(context.Context) error   { return nil }
+func (cancelledSpanProcessor) ForceFlush(context.Context) error { return nil }
+
+// shutdownHook runs fn when the tracer provider shuts down, tying background
+// work such as pollers to the provider lifetime.
+type shutdownHook func()
+
+func (shutdownHook) OnStart(context.Context, sdktrace.ReadWriteSpan) {}
+func (shutdownHook) OnEnd(sdktrace.ReadOnlySpan)                     {}
+func (h shutdownHook) Shutdown(context.Context) error {
+	h()
+	return nil
+}
+func (shutdownHook) ForceFlush(context.Context) error { return nil }
+
+// newExportProcessor batches spans to exporter, passing them through the
+// export filters enabled in config first.
+func newExportProcessor(exporter sdktrace.SpanExporter, config Config, stats *exportStats) (sdktrace.SpanProcessor, error) {
+	if config.StampSourceInstance {
+		if instance := sourceInstance(); instance != "" {
+			exporter = sourceInstanceExporter{SpanExporter: exporter, instance: instance}
+		}
+	}
+	if config.MaxConcurrentExports > 0 {
+		exporter = newLimitedExporter(exporter, config.MaxConcurrentExports)
+	}
+	batchOpts := []sdktrace.BatchSpanProcessorOption{sdktrace.WithMaxQueueSize(int(stats.maxQueue))}
+	if config.ExportTimeout > 0 {
+		batchOpts = append(batchOpts, sdktrace.WithExportTimeout(config.ExportTimeout))
+	}
+	var processor sdktrace.SpanProcessor = statsProcessor{
+		SpanProcessor: sdktrace.NewBatchSpanProcessor(statsExporter{SpanExporter: exporter, stats: stats}, batchOpts...),
+		stats:         stats,
+	}
+	if len(config.PathRedactions) > 0 {
+		redactions, err := compilePathRedactions(config.PathRedactions)
+		if err != nil {
+			return nil, err
+		}
+		processor = pathRedactionProcessor{SpanProcessor: processor, redactions: redactions}
+	}
+	if config.DropOrphanSpans {
+		processor = newOrphanFilterProcessor(processor)
+	}
+	if config.MinSpanDuration > 0 {
+		processor = minDurationProcessor{SpanProcessor: processor, min: config.MinSpanDuration}
+	}
+	if config.CoalesceWindow > 0 {
+		processor = newCoalesceProcessor(processor, config.CoalesceWindow)
+	}
+	return processor, nil
+}
+
+// minDurationProcessor drops spans shorter than min. Local roots and error
+// spans are always kept.
+type minDurationProcessor struct {
+	sdktrace.SpanProcessor
+
+	min time.Duration
+}
+
+func (p minDurationProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	if s.EndTime().Sub(s.StartTime()) < p.min && !isLocalRoot(s.Parent()) && s.Status().Code != codes.Error {
+		return
+	}
+	p.SpanProcessor.OnEnd(s)
+}
+
+// limitedExporter bounds the number of ExportSpans calls in flight. Callers
+// over the limit wait, or give up when their context is done.
+type limitedExporter struct {
+	sdktrace.SpanExporter
+
+	slots chan struct{}
+}
+
+func newLimitedExporter(exporter sdktrace.SpanExporter, limit int) limitedExporter {
+	return limitedExporter{SpanExporter: exporter, slots: make(chan struct{}, limit)}
+}
+
+func (e limitedExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	select {
+	case e.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-e.slots }()
+	return e.SpanExporter.ExportSpans(ctx, spans)
+}
+
+// ratioFilterProcessor forwards a trace ID based ratio of spans, so traces
+// are kept or dropped as a whole.
+type ratioFilterProcessor struct {
+	sdktrace.SpanProcessor
+
+	sampler sdktrace.Sampler
+}
+
+func newRatioFilterProcessor(next sdktrace.SpanProcessor, ratio float64) ratioFilterProcessor {
+	return ratioFilterProcessor{SpanProcessor: next, sampler: sdktrace.TraceIDRatioBased(clampRate(ratio))}
+}
+
+func (p ratioFilterProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	result := p.sampler.ShouldSample(sdktrace.SamplingParameters{
+		ParentContext: context.Background(),
+		TraceID:       s.SpanContext().TraceID(),
+	})
+	if result.Decision == sdktrace.RecordAndSample {
+		p.SpanProcessor.OnEnd(s)
+	}
+}
+
+func isLocalRoot(parent trace.SpanContext) bool {
+	return !parent.IsValid() || parent.IsRemote()
+}
+
+// orphanFilterProcessor only forwards spans of traces whose local root span
+// is recording in this process. Spans that were sampled while their local
+// root was not would show up as partial traces in the backend.
+type orphanFilterProcessor struct {
+	sdktrace.SpanProcessor
+
+	mu    sync.Mutex
+	roots map[trace.TraceID]int // open local roots per trace
+}
+
+func newOrphanFilterProcessor(next sdktrace.SpanProcessor) *orphanFilterProcessor {
+	return &orphanFilterProcessor{SpanProcessor: next, roots: map[trace.TraceID]int{}}
+}
+
+func (p *orphanFilterProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	if isLocalRoot(s.Parent()) {
+		p.mu.Lock()
+		p.roots[s.SpanContext().TraceID()]++
+		p.mu.Unlock()
+	}
+	p.SpanProcessor.OnStart(parent, s)
+}
+
+func (p *orphanFilterProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	traceID := s.SpanContext().TraceID()
+
+	p.mu.Lock()
+	open := p.roots[traceID]
+	if open > 0 && isLocalRoot(s.Parent()) {
+		if open == 1 {
+			delete(p.roots, traceID)
+		} else {
+			p.roots[traceID] = open - 1
+		}
+	}
+	p.mu.Unlock()
+
+	if open > 0 {
+		p.SpanProcessor.OnEnd(s)
+	}
+}
+
+// spanWatchdogProcessor force-ends spans that stay open longer than max,
+// so leaked spans from buggy background code are still exported.
+type spanWatchdogProcessor struct {
+	max  time.Duration
+	stop chan struct{}
+	once sync.Once
+
+	mu    sync.Mutex
+	spans map[trace.SpanID]sdktrace.ReadWriteSpan
+}
+
+func newSpanWatchdogProcessor(max time.Duration) *spanWatchdogProcessor {
+	p := &spanWatchdogProcessor{
+		max:   max,
+		stop:  make(chan struct{}),
+		spans: map[trace.SpanID]sdktrace.ReadWriteSpan{},
+	}
+	go p.run()
+	return p
+}
+
+func (p *spanWatchdogProcessor) run() {
+	// NewTicker panics on a non-positive interval, which p.max/2 is for 1ns.
+	ticker := time.NewTicker(max(p.max/2, time.Millisecond))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case now := <-ticker.C:
+			p.endExpired(now)
+		}
+	}
+}
+
+func (p *spanWatchdogProcessor) endExpired(now time.Time) {
+	var expired []sdktrace.ReadWriteSpan
+	p.mu.Lock()
+	for _, s := range p.spans {
+		if now.Sub(s.StartTime()) > p.max {
+			expired = append(expired, s)
+		}
+	}
+	p.mu.Unlock()
+
+	// End calls OnEnd, which takes the lock, so end outside of it.
+	for _, s := range expired {
+		s.AddEvent("span.force_ended", trace.WithAttributes(attribute.String("max_span_duration", p.max.String())))
+		s.SetStatus(codes.Error, "span exceeded max duration")
+		s.End()
+	}
+}
+
+func (p *spanWatchdogProcessor) OnStart(_ context.Context, s sdktrace.ReadWriteSpan) {
+	p.mu.Lock()
+	p.spans[s.SpanContext().SpanID()] = s
+	p.mu.Unlock()
+}
+
+func (p *spanWatchdogProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	p.mu.Lock()
+	delete(p.spans, s.SpanContext().SpanID())
+	p.mu.Unlock()
+}
+
+func (p *spanWatchdogProcessor) Shutdown(context.Context) error {
+	p.once.Do(func() { close(p.stop) })
+	return nil
+}
+
+func (*spanWatchdogProcessor) ForceFlush(context.Context) error { return nil }
+
+// installedShutdownMarker is the marker of the provider installed by
+// InitTracer, so BeginShutdown leaves NewIsolatedProvider providers alone.
+var installedShutdownMarker atomic.Pointer[shutdownMarkerProcessor]
+
+// BeginShutdown marks every span started from now on by the provider
+// installed by InitTracer with deployment.shutting_down=true. Call it when
+// graceful shutdown starts draining in-flight requests, before shutting the
+// tracer provider down. It is a no-op before InitTracer.
+func BeginShutdown() {
+	if marker := installedShutdownMarker.Load(); marker != nil {
+		marker.shuttingDown.Store(true)
+	}
+}
+
+// shutdownMarkerProcessor tags spans started once BeginShutdown was called
+// or its provider began shutting down.
+type shutdownMarkerProcessor struct {
+	shuttingDown atomic.Bool
+}
+
+func (p *shutdownMarkerProcessor) OnStart(_ context.Context, s sdktrace.ReadWriteSpan) {
+	if p.shuttingDown.Load() {
+		s.SetAttributes(attribute.Bool("deployment.shutting_down", true))
+	}
+}
+
+func (*shutdownMarkerProcessor) OnEnd(sdktrace.ReadOnlySpan) {}
+
+func (p *shutdownMarkerProcessor) Shutdown(context.Context) error {
+	p.shuttingDown.Store(true)
+	return nil
+}
+
+func (*shutdownMarkerProcessor) ForceFlush(context.Context) error { return nil }
+
+// registerSpanProcessors adds the enriching processors to tp and returns its
+// shutdown marker.
+func registerSpanProcessors(tp *sdktrace.TracerProvider, config Config) *shutdownMarkerProcessor {
+	tp.RegisterSpanProcessor(deploymentColorProcessor{})
+	tp.RegisterSpanProcessor(regionProcessor{})
+	tp.RegisterSpanProcessor(spanDefaultsProcessor{})
+	marker := &shutdownMarkerProcessor{}
+	tp.RegisterSpanProcessor(marker)
+	if config.MaxSpanDuration > 0 {
+		tp.RegisterSpanProcessor(newSpanWatchdogProcessor(config.MaxSpanDuration))
+	}
+	if config.Verbose {
+		tp.RegisterSpanProcessor(spanLogProcessor{logger: loggerOrDefault(config.Logger)})
+	}
+	if config.CancelledSpans == CancelledSpanTag {
+		tp.RegisterSpanProcessor(cancelledSpanProcessor{})
+	}
+	if len(config.AttributeSources) > 0 {
+		sources, errs := parseAttributeSources(config.AttributeSources)
+		for _, err := range errs {
+			loggerOrDefault(config.Logger).Printf("Skipping %v", err)
+		}
+		tp.RegisterSpanProcessor(attributeSourceProcessor{sources: sources})
+	}
+	if config.MaxIdenticalErrorEvents > 0 {
+		tp.RegisterSpanProcessor(newErrorEventsProcessor(tp, config.MaxIdenticalErrorEvents))
+	}
+	if config.KeepTraces {
+		tp.RegisterSpanProcessor(newKeepTraceProcessor(config.KeepTraceEvents))
+	}
+	if config.SpanAggregator != nil {
+		tp.RegisterSpanProcessor(aggregatorProcessor{aggregator: config.SpanAggregator})
+	}
+	return marker
+}
@@ -0,0 +1,133 @@
+package tracer
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// tracerPipeline is what newTracerProvider built, for status reporting.
+type tracerPipeline struct {
+	sampler sdktrace.Sampler
+	dynamic *DynamicSampler
+	stats   []*exportStats
+	marker  *shutdownMarkerProcessor
+}
+
+// newTracerProvider builds the provider described by config without touching
+// any global state. The provider is nil when no tool is recognised or in
+// ValidateOnly mode, where the validation outcome is returned as the error.
+func newTracerProvider(ctx context.Context, serviceName, environment, moduleName string, config Config) (*sdktrace.TracerProvider, tracerPipeline, error) {
+	var pipeline tracerPipeline
+	sampler, dynamicSampler, err := buildSampler(config)
+	if err != nil {
+		return nil, pipeline, err
+	}
+	pipeline.sampler, pipeline.dynamic = sampler, dynamicSampler
+	exporterRates, err := parseExporterSamplingRates(config.ExporterSamplingRates)
+	if err != nil {
+		return nil, pipeline, err
+	}
+
+	exporters, err := newExporters(ctx, config)
+	if err != nil {
+		if config.SoftFail && !config.ValidateOnly {
+			loggerOrDefault(config.Logger).Printf("Warning: failed to create trace exporter, tracing disabled: %v", err)
+			return sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.NeverSample())), pipeline, nil
+		}
+		return nil, pipeline, err
+	}
+
+	if config.ValidateOnly {
+		res, err := BuildResource(ctx, serviceName, environment, moduleName, config)
+		if err == nil {
+			err = validateTracer(ctx, config, exporters, res)
+		}
+		shutdownExporters(ctx, exporters)
+		return nil, pipeline, err
+	}
+
+	if len(exporters) == 0 {
+		return nil, pipeline, nil
+	}
+
+	res, err := BuildResource(ctx, serviceName, environment, moduleName, config)
+	if err != nil {
+		loggerOrDefault(config.Logger).Printf("Failed to create tracer resource: %v", err)
+		shutdownExporters(ctx, exporters)
+		return nil, pipeline, err
+	}
+	if config.SelfCheck {
+		selfCheck(ctx, exporters, res, loggerOrDefault(config.Logger))
+	}
+
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithSampler(sampler),
+		sdktrace.WithResource(res),
+	}
+	maxQueue := bspMaxQueueSize()
+	for _, e := range exporters {
+		stats := &exportStats{
+			tool:     e.tool,
+			canary:   e.canary,
+			maxQueue: int64(maxQueue),
+			probe: func(ctx context.Context) error {
+				return e.exporter.ExportSpans(ctx, []sdktrace.ReadOnlySpan{canarySpan("tracing.status_probe", res)})
+			},
+		}
+		pipeline.stats = append(pipeline.stats, stats)
+		processor, err := newExportProcessor(e.exporter, config, stats)
+		if err != nil {
+			shutdownExporters(ctx, exporters)
+			return nil, pipeline, err
+		}
+		if e.canary {
+			processor = newRatioFilterProcessor(processor, config.Canary.Ratio)
+		} else if rate, ok := exporterRates[e.tool]; ok {
+			processor = newRatioFilterProcessor(processor, rate)
+		}
+		opts = append(opts, sdktrace.WithSpanProcessor(processor))
+	}
+	tp := sdktrace.NewTracerProvider(opts...)
+
+	pipeline.marker = registerSpanProcessors(tp, config)
+	if dynamicSampler != nil && config.TargetSpansPerSecond > 0 {
+		if config.SamplingRateFetcher != nil {
+			loggerOrDefault(config.Logger).Printf("SamplingRateFetcher is set, ignoring TargetSpansPerSecond")
+		} else {
+			adaptive := newAdaptiveRatio(dynamicSampler, config.TargetSpansPerSecond, configuredRate(config.TracerSamplingRate))
+			config.SamplingRateFetcher = adaptive.next
+			config.SamplingPollInterval = config.AdaptiveInterval
+			if config.SamplingPollInterval <= 0 {
+				config.SamplingPollInterval = defaultAdaptiveInterval
+			}
+		}
+	}
+	if dynamicSampler != nil {
+		stop := startSamplingUpdates(dynamicSampler, config)
+		tp.RegisterSpanProcessor(shutdownHook(stop))
+	}
+	return tp, pipeline, nil
+}
+
+// NewIsolatedProvider builds a tracer provider from cfg for a library that
+// needs its own sampling and exporters. Unlike InitTracer it never touches
+// the global provider, propagator, error handler or StatusHandler, and
+// ignores gin and Clock. The service name comes from OTEL_SERVICE_NAME or
+// the executable name. Call the returned function to shut it down.
+func NewIsolatedProvider(ctx context.Context, cfg Config) (*sdktrace.TracerProvider, func(context.Context) error, error) {
+	if strings.TrimSpace(cfg.TracingTool) == "" {
+		return nil, nil, errors.New("tracing tool not configured")
+	}
+	cfg.ValidateOnly = false
+	tp, _, err := newTracerProvider(ctx, "", "", "", cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if tp == nil {
+		return nil, nil, errors.New("tracing tool not recognised: " + cfg.TracingTool)
+	}
+	return tp, tp.Shutdown, nil
+}
@@ -0,0 +1,113 @@
+package tracer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+const defaultRedactionPlaceholder = "{redacted}"
+
+// PathRedaction replaces the parts of span names and http.route values
+// matching Pattern with Replacement ("{redacted}" when empty). With Segment
+// set, Pattern is matched against each "/"-separated segment on its own and
+// a matching segment is replaced whole.
+type PathRedaction struct {
+	Pattern     string
+	Replacement string
+	Segment     bool
+}
+
+// Common path redactions.
+var (
+	RedactUUIDs = PathRedaction{
+		Pattern:     `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+		Replacement: "{uuid}",
+	}
+	RedactEmails = PathRedaction{
+		Pattern:     `[^/@\s]+@[^/@\s]+\.[^/@\s]+`,
+		Replacement: "{email}",
+	}
+	RedactNumericIDs = PathRedaction{
+		Pattern:     `^\d+$`,
+		Replacement: "{id}",
+		Segment:     true,
+	}
+)
+
+type compiledRedaction struct {
+	pattern     *regexp.Regexp
+	replacement string
+	segment     bool
+}
+
+func compilePathRedactions(redactions []PathRedaction) ([]compiledRedaction, error) {
+	compiled := make([]compiledRedaction, 0, len(redactions))
+	for _, r := range redactions {
+		pattern, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path redaction pattern %q: %w", r.Pattern, err)
+		}
+		replacement := r.Replacement
+		if replacement == "" {
+			replacement = defaultRedactionPlaceholder
+		}
+		compiled = append(compiled, compiledRedaction{pattern: pattern, replacement: replacement, segment: r.Segment})
+	}
+	return compiled, nil
+}
+
+func redactPath(redactions []compiledRedaction, s string) string {
+	for _, r := range redactions {
+		if !r.segment {
+			s = r.pattern.ReplaceAllString(s, r.replacement)
+			continue
+		}
+		segments := strings.Split(s, "/")
+		for i, segment := range segments {
+			if r.pattern.MatchString(segment) {
+				segments[i] = r.replacement
+			}
+		}
+		s = strings.Join(segments, "/")
+	}
+	return s
+}
+
+// pathRedactionProcessor rewrites span names and http.route before export.
+type pathRedactionProcessor struct {
+	sdktrace.SpanProcessor
+
+	redactions []compiledRedaction
+}
+
+func (p pathRedactionProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	p.SpanProcessor.OnEnd(redactedSpan{ReadOnlySpan: s, redactions: p.redactions})
+}
+
+// redactedSpan is a read-only view of a span with redacted name and route.
+type redactedSpan struct {
+	sdktrace.ReadOnlySpan
+
+	redactions []compiledRedaction
+}
+
+func (s redactedSpan) Name() string {
+	return redactPath(s.redactions, s.ReadOnlySpan.Name())
+}
+
+func (s redactedSpan) Attributes() []attribute.KeyValue {
+	attrs := s.ReadOnlySpan.Attributes()
+	redacted := make([]attribute.KeyValue, len(attrs))
+	for i, attr := range attrs {
+		if attr.Key == semconv.HTTPRouteKey {
+			attr = attr.Key.String(redactPath(s.redactions, attr.Value.AsString()))
+		}
+		redacted[i] = attr
+	}
+	return redacted
+}
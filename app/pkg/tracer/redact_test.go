@@ -0,0 +1,26 @@
+package tracer
+
+import "testing"
+
+func TestRedactPath(t *testing.T) {
+	redactions, err := compilePathRedactions([]PathRedaction{RedactNumericIDs, RedactUUIDs})
+	if err != nil {
+		t.Fatalf("compilePathRedactions: %v", err)
+	}
+	tests := []struct {
+		in, want string
+	}{
+		{"GET /orders/123", "GET /orders/{id}"},
+		{"/orders/123/items", "/orders/{id}/items"},
+		{"/orders/1/2", "/orders/{id}/{id}"},
+		{"/a/1/2/3/b", "/a/{id}/{id}/{id}/b"},
+		{"/v2/orders", "/v2/orders"},
+		{"/orders/123abc", "/orders/123abc"},
+		{"/users/123e4567-e89b-12d3-a456-426614174000/1", "/users/{uuid}/{id}"},
+	}
+	for _, tt := range tests {
+		if got := redactPath(redactions, tt.in); got != tt.want {
+			t.Errorf("redactPath(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package tracer
+
+import (
+	"context"
+	"os"
+	"runtime/debug"
+	"sync/atomic"
+
+	"go.opentelemetry.io/contrib/detectors/gcp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+// BuildResource returns the resource InitTracer attaches to every span, so
+// meter and logger providers can be labelled the same way. The GCP detector
+// and the telemetry.sdk.* attributes are only used when config.TracingTool
+// selects GCP.
+func BuildResource(ctx context.Context, serviceName, environment, moduleName string, config Config) (*resource.Resource, error) {
+	serviceName = resolveServiceName(serviceName, config.Logger)
+
+	opts := []resource.Option{
+		resource.WithSchemaURL(semconv.SchemaURL),
+	}
+	if hasTool(config, ToolGCP) {
+		// Use the GCP resource detector to detect information about the GCP platform
+		opts = append(opts, resource.WithDetectors(gcp.NewDetector()), resource.WithTelemetrySDK())
+	}
+	opts = append(opts, resource.WithAttributes(
+		semconv.ServiceNameKey.String(serviceName),
+		attribute.String("environment", environment),
+		attribute.String("module", moduleName),
+	))
+	if color := deploymentColor(config); color != "" {
+		opts = append(opts, resource.WithAttributes(attribute.String("deployment.color", color)))
+	}
+	if previewID := configOrEnv(config.PreviewID, "PREVIEW_ID"); previewID != "" {
+		opts = append(opts, resource.WithAttributes(attribute.String("deployment.preview_id", previewID)))
+	}
+	if branch := configOrEnv(config.GitBranch, "GIT_BRANCH"); branch != "" {
+		opts = append(opts, resource.WithAttributes(semconv.VCSRefHeadName(branch)))
+	}
+	if deployment := configOrEnv(config.K8sDeploymentName, "K8S_DEPLOYMENT_NAME"); deployment != "" {
+		opts = append(opts, resource.WithAttributes(semconv.K8SDeploymentName(deployment)))
+	}
+	if replicaSet := configOrEnv(config.K8sReplicaSetName, "K8S_REPLICASET_NAME"); replicaSet != "" {
+		opts = append(opts, resource.WithAttributes(semconv.K8SReplicaSetName(replicaSet)))
+	}
+	if digest := configOrEnv(config.ContainerImageDigest, "CONTAINER_IMAGE_DIGEST"); digest != "" {
+		opts = append(opts, resource.WithAttributes(attribute.String("container.image.digest", digest)))
+	}
+
+	if config.UseBuildInfo {
+		opts = append(opts, resource.WithAttributes(buildInfoAttributes()...))
+	}
+
+	for _, provider := range config.AttributeProviders {
+		attrs, err := provider.Attributes(ctx)
+		if err != nil {
+			// Only the error is logged, never the attribute values.
+			loggerOrDefault(config.Logger).Printf("Failed to fetch resource attributes from provider: %v", err)
+			continue
+		}
+		opts = append(opts, resource.WithAttributes(attrs...))
+	}
+
+	return resource.New(ctx, opts...)
+}
+
+// buildInfoAttributes reads service.version, vcs.ref.head.revision,
+// vcs.modified and build.time from the binary's build info. Values missing
+// from it, e.g. under go run or without VCS stamping, are left out.
+func buildInfoAttributes() []attribute.KeyValue {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil
+	}
+	var attrs []attribute.KeyValue
+	if version := info.Main.Version; version != "" && version != "(devel)" {
+		attrs = append(attrs, semconv.ServiceVersion(version))
+	}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			attrs = append(attrs, semconv.VCSRefHeadRevision(setting.Value))
+		case "vcs.modified":
+			attrs = append(attrs, attribute.Bool("vcs.modified", setting.Value == "true"))
+		case "vcs.time":
+			attrs = append(attrs, attribute.String("build.time", setting.Value))
+		}
+	}
+	return attrs
+}
+
+// AttributeProvider supplies resource attributes fetched at init, e.g. tenant
+// tokens held in a secrets manager such as Vault. Their values are never
+// logged.
+type AttributeProvider interface {
+	Attributes(ctx context.Context) ([]attribute.KeyValue, error)
+}
+
+// deploymentColor returns config.DeploymentColor, falling back to the
+// DEPLOYMENT_COLOR environment variable.
+func deploymentColor(config Config) string {
+	return configOrEnv(config.DeploymentColor, "DEPLOYMENT_COLOR")
+}
+
+var deploymentColorOverride atomic.Value // string
+
+// SetDeploymentColor overrides deployment.color at runtime, e.g. when a canary
+// is promoted. The resource cannot change after init, so the override is set
+// on each span started afterwards. An empty color removes the override.
+func SetDeploymentColor(color string) {
+	deploymentColorOverride.Store(color)
+}
+
+// deploymentColorProcessor stamps the runtime deployment color override.
+type deploymentColorProcessor struct{}
+
+func (deploymentColorProcessor) OnStart(_ context.Context, s sdktrace.ReadWriteSpan) {
+	if color, _ := deploymentColorOverride.Load().(string); color != "" {
+		s.SetAttributes(attribute.String("deployment.color", color))
+	}
+}
+
+func (deploymentColorProcessor) OnEnd(sdktrace.ReadOnlySpan)      {}
+func (deploymentColorProcessor) Shutdown(context.Context) error   { return nil }
+func (deploymentColorProcessor) ForceFlush(context.Context) error { return nil }
+
+var (
+	regionOverride      atomic.Value // string
+	environmentOverride atomic.Value // string
+)
+
+// SetCloudRegion overrides cloud.region at runtime, e.g. from a region-aware
+// sidecar once it is known. Like SetDeploymentColor it is set on each span
+// started afterwards, since the resource is immutable and re-creating the
+// provider would drop spans in flight; backends grouping by resource keep
+// seeing the init-time value. An empty region removes the override.
+func SetCloudRegion(region string) {
+	regionOverride.Store(region)
+}
+
+// SetEnvironment overrides the environment attribute at runtime the same
+// way as SetCloudRegion.
+func SetEnvironment(environment string) {
+	environmentOverride.Store(environment)
+}
+
+// regionProcessor stamps the runtime region and environment overrides.
+type regionProcessor struct{}
+
+func (regionProcessor) OnStart(_ context.Context, s sdktrace.ReadWriteSpan) {
+	if region, _ := regionOverride.Load().(string); region != "" {
+		s.SetAttributes(semconv.CloudRegion(region))
+	}
+	if environment, _ := environmentOverride.Load().(string); environment != "" {
+		s.SetAttributes(attribute.String("environment", environment))
+	}
+}
+
+func (regionProcessor) OnEnd(sdktrace.ReadOnlySpan)      {}
+func (regionProcessor) Shutdown(context.Context) error   { return nil }
+func (regionProcessor) ForceFlush(context.Context) error { return nil }
+
+func configOrEnv(value, env string) string {
+	if value != "" {
+		return value
+	}
+	return os.Getenv(env)
+}
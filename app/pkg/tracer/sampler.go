@@ -0,0 +1,588 @@
+package tracer
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const defaultSamplingPollInterval = time.Minute
+
+// DynamicSampler is a TraceIDRatioBased sampler whose ratio can be changed
+// while the application is running. It is safe for concurrent use.
+type DynamicSampler struct {
+	ratio   atomic.Uint64 // math.Float64bits of the current ratio
+	sampler atomic.Pointer[sdktrace.Sampler]
+	sampled atomic.Pointer[atomic.Int64] // counts sampled decisions, for adaptiveRatio
+	warming atomic.Bool                  // set until WarmupDuration elapses, see warmupSampler
+}
+
+// NewDynamicSampler returns a DynamicSampler starting at ratio.
+func NewDynamicSampler(ratio float64) *DynamicSampler {
+	s := &DynamicSampler{}
+	s.SetRatio(ratio)
+	return s
+}
+
+// SetRatio replaces the sampling ratio, clamped to [0, 1].
+func (s *DynamicSampler) SetRatio(ratio float64) {
+	ratio = clampRate(ratio)
+	sampler := sdktrace.TraceIDRatioBased(ratio)
+	s.sampler.Store(&sampler)
+	s.ratio.Store(math.Float64bits(ratio))
+}
+
+func (s *DynamicSampler) Ratio() float64 {
+	return math.Float64frombits(s.ratio.Load())
+}
+
+func (s *DynamicSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	result := (*s.sampler.Load()).ShouldSample(p)
+	if result.Decision == sdktrace.RecordAndSample {
+		if sampled := s.sampled.Load(); sampled != nil {
+			sampled.Add(1)
+		}
+	}
+	return result
+}
+
+func (s *DynamicSampler) Description() string {
+	return fmt.Sprintf("DynamicSampler{%g}", s.Ratio())
+}
+
+// StartSamplingPoller fetches a sampling ratio immediately and then every
+// interval, applying it to sampler. Fetch errors are logged and the last good
+// ratio is kept. The returned function stops the poller.
+func StartSamplingPoller(sampler *DynamicSampler, interval time.Duration, fetch func(ctx context.Context) (float64, error)) (stop func()) {
+	return startSamplingPoller(sampler, interval, fetch, packageLogger())
+}
+
+func startSamplingPoller(sampler *DynamicSampler, interval time.Duration, fetch func(ctx context.Context) (float64, error), logger Logger) (stop func()) {
+	if interval <= 0 {
+		interval = defaultSamplingPollInterval
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	poll := func() {
+		fetchCtx, fetchCancel := context.WithTimeout(ctx, interval)
+		defer fetchCancel()
+		ratio, err := fetch(fetchCtx)
+		if err != nil {
+			logger.Printf("Failed to fetch sampling rate, keeping %f: %v", sampler.Ratio(), err)
+			return
+		}
+		if clampRate(ratio) != sampler.Ratio() {
+			sampler.SetRatio(ratio)
+			logger.Printf("Sampling rate updated to: %f", sampler.Ratio())
+		}
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		poll()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				poll()
+			}
+		}
+	}()
+	return cancel
+}
+
+// startSamplingUpdates drives sampler at runtime: full sampling for
+// config.WarmupDuration, then the configured rate, then the rate poller when
+// a fetcher is set. The returned function stops all of it.
+func startSamplingUpdates(sampler *DynamicSampler, config Config) (stop func()) {
+	logger := loggerOrDefault(config.Logger)
+	if config.WarmupDuration <= 0 {
+		return startSamplingPoller(sampler, config.SamplingPollInterval, config.SamplingRateFetcher, logger)
+	}
+
+	logger.Printf("Sampling all traces during warmup for %s", config.WarmupDuration)
+	var (
+		mu         sync.Mutex
+		stopped    bool
+		stopPoller func()
+	)
+	timer := time.AfterFunc(config.WarmupDuration, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if stopped {
+			return
+		}
+		sampler.warming.Store(false)
+		sampler.SetRatio(configuredRate(config.TracerSamplingRate))
+		logger.Printf("Warmup finished, sampling rate set to: %f", sampler.Ratio())
+		if config.SamplingRateFetcher != nil {
+			stopPoller = startSamplingPoller(sampler, config.SamplingPollInterval, config.SamplingRateFetcher, logger)
+		}
+	})
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		stopped = true
+		timer.Stop()
+		if stopPoller != nil {
+			stopPoller()
+		}
+	}
+}
+
+// buildSampler composes the sampler described by config. The returned
+// DynamicSampler is non-nil when the root ratio can change at runtime.
+func buildSampler(config Config) (sdktrace.Sampler, *DynamicSampler, error) {
+	rules, err := compileRouteRules(config.RouteSamplingRules)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	endpoints, err := compileEndpointOverrides(config.EndpointSamplingOverrides)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var dynamicSampler *DynamicSampler
+	sampler := initializeTraceSampler(config.TracerSamplingRate, loggerOrDefault(config.Logger))
+	if config.SamplingRateFetcher != nil || config.WarmupDuration > 0 || len(rules) > 0 || len(endpoints) > 0 ||
+		config.InternalSamplingRate != "" || config.SamplingAttributes != "" || config.RecordSamplingReason ||
+		config.TargetSpansPerSecond > 0 {
+		var root sdktrace.Sampler
+		if config.WarmupDuration > 0 {
+			dynamicSampler = NewDynamicSampler(1)
+			dynamicSampler.warming.Store(true)
+			root = dynamicSampler
+		} else if config.SamplingRateFetcher != nil || config.TargetSpansPerSecond > 0 {
+			dynamicSampler = NewDynamicSampler(configuredRate(config.TracerSamplingRate))
+			root = dynamicSampler
+		} else {
+			root = newRatioSampler(configuredRate(config.TracerSamplingRate))
+		}
+		if len(rules) > 0 {
+			root = routeSampler{rules: rules, next: root}
+		}
+		if len(endpoints) > 0 {
+			root = endpointSampler{overrides: endpoints, next: root}
+		}
+		if config.InternalSamplingRate != "" {
+			root = spanKindSampler{server: root, internal: newRatioSampler(configuredRate(config.InternalSamplingRate))}
+		}
+		if config.WarmupDuration > 0 {
+			root = warmupSampler{dynamic: dynamicSampler, next: root}
+		}
+		if config.SamplingAttributes != "" {
+			root = probabilityAttributeSampler{next: root, scheme: config.SamplingAttributes}
+		}
+		sampler = sdktrace.ParentBased(root)
+		if config.RecordSamplingReason {
+			sampler = samplingReasonSampler{next: sampler, root: root}
+		}
+	}
+	if config.CancelledSpans == CancelledSpanDrop {
+		sampler = cancelledSampler{next: sampler}
+	}
+	if config.StrictHeadSampling {
+		sampler = strictHeadSampler{root: sampler}
+	}
+	if config.DoNotTraceHeader != "" || config.NoTraceHeader != "" {
+		// Outermost, so it wins over anything forcing sampling.
+		sampler = doNotTraceSampler{next: sampler}
+	}
+	return sampler, dynamicSampler, nil
+}
+
+type doNotTraceKey struct{}
+
+// WithDoNotTrace marks ctx so that no span started under it is sampled.
+func WithDoNotTrace(ctx context.Context) context.Context {
+	return context.WithValue(ctx, doNotTraceKey{}, true)
+}
+
+func doNotTrace(ctx context.Context) bool {
+	v, _ := ctx.Value(doNotTraceKey{}).(bool)
+	return v
+}
+
+// doNotTraceSampler drops every span started under a WithDoNotTrace context.
+type doNotTraceSampler struct {
+	next sdktrace.Sampler
+}
+
+func (s doNotTraceSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	if doNotTrace(p.ParentContext) {
+		return sdktrace.SamplingResult{Decision: sdktrace.Drop}
+	}
+	return s.next.ShouldSample(p)
+}
+
+func (s doNotTraceSampler) Description() string {
+	return s.next.Description()
+}
+
+// strictHeadSampler consults its root sampler for root spans only. Every
+// span with a parent, local or remote, copies the parent's sampled flag, so
+// no other sampler (cancelled span dropping, AlwaysSample when no rate is
+// configured) can split a trace.
+type strictHeadSampler struct {
+	root sdktrace.Sampler
+}
+
+func (s strictHeadSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	parent := trace.SpanContextFromContext(p.ParentContext)
+	if !parent.IsValid() {
+		return s.root.ShouldSample(p)
+	}
+	decision := sdktrace.Drop
+	if parent.IsSampled() {
+		decision = sdktrace.RecordAndSample
+	}
+	return sdktrace.SamplingResult{Decision: decision, Tracestate: parent.TraceState()}
+}
+
+func (s strictHeadSampler) Description() string {
+	return fmt.Sprintf("StrictHeadSampler{%s}", s.root.Description())
+}
+
+// warmupSampler samples every root span while its DynamicSampler is warming
+// up, including those route, endpoint and span kind overrides would drop.
+type warmupSampler struct {
+	dynamic *DynamicSampler
+	next    sdktrace.Sampler
+}
+
+func (s warmupSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	if s.dynamic.warming.Load() {
+		return sdktrace.AlwaysSample().ShouldSample(p)
+	}
+	return s.next.ShouldSample(p)
+}
+
+func (s warmupSampler) Description() string {
+	return "WarmupSampler{" + s.next.Description() + "}"
+}
+
+// RouteSamplingRule samples root spans whose route matches Pattern, a regular
+// expression such as `^/api/v\d+/payments`, at Rate.
+type RouteSamplingRule struct {
+	Pattern string
+	Rate    float64
+}
+
+type compiledRouteRule struct {
+	pattern *regexp.Regexp
+	sampler ratioSampler
+}
+
+func compileRouteRules(rules []RouteSamplingRule) ([]compiledRouteRule, error) {
+	compiled := make([]compiledRouteRule, 0, len(rules))
+	for _, rule := range rules {
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid route sampling pattern %q: %w", rule.Pattern, err)
+		}
+		compiled = append(compiled, compiledRouteRule{
+			pattern: pattern,
+			sampler: newRatioSampler(rule.Rate),
+		})
+	}
+	return compiled, nil
+}
+
+// routeSampler applies the first rule matching the span route and falls back
+// to next when none match.
+type routeSampler struct {
+	rules []compiledRouteRule
+	next  sdktrace.Sampler
+}
+
+func (s routeSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	return s.match(p).ShouldSample(p)
+}
+
+func (s routeSampler) match(p sdktrace.SamplingParameters) sdktrace.Sampler {
+	if rule, ok := s.matchRule(p); ok {
+		return rule.sampler
+	}
+	return s.next
+}
+
+func (s routeSampler) matchRule(p sdktrace.SamplingParameters) (compiledRouteRule, bool) {
+	route := spanRoute(p)
+	for _, rule := range s.rules {
+		if rule.pattern.MatchString(route) {
+			return rule, true
+		}
+	}
+	return compiledRouteRule{}, false
+}
+
+func (s routeSampler) Description() string {
+	return fmt.Sprintf("RouteSampler{rules:%d,%s}", len(s.rules), s.next.Description())
+}
+
+// spanKindSampler samples server root spans, entering through the
+// middleware, with server and the roots of work started internally with
+// internal.
+type spanKindSampler struct {
+	server   sdktrace.Sampler
+	internal sdktrace.Sampler
+}
+
+func (s spanKindSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	return s.match(p).ShouldSample(p)
+}
+
+func (s spanKindSampler) match(p sdktrace.SamplingParameters) sdktrace.Sampler {
+	if p.Kind == trace.SpanKindServer {
+		return s.server
+	}
+	return s.internal
+}
+
+func (s spanKindSampler) Description() string {
+	return fmt.Sprintf("SpanKindSampler{server:%s,internal:%s}", s.server.Description(), s.internal.Description())
+}
+
+func compileEndpointOverrides(overrides map[string]float64) (map[string]ratioSampler, error) {
+	compiled := make(map[string]ratioSampler, len(overrides))
+	for key, rate := range overrides {
+		endpoint, ok := normalizeEndpoint(key)
+		if !ok {
+			return nil, fmt.Errorf("invalid endpoint sampling key %q, want \"METHOD route\"", key)
+		}
+		compiled[endpoint] = newRatioSampler(rate)
+	}
+	return compiled, nil
+}
+
+func normalizeEndpoint(key string) (string, bool) {
+	fields := strings.Fields(key)
+	if len(fields) != 2 {
+		return "", false
+	}
+	return strings.ToUpper(fields[0]) + " " + fields[1], true
+}
+
+// endpointSampler applies the override for the span's method and route, set
+// by otelgin when the span starts, and falls back to next otherwise.
+type endpointSampler struct {
+	overrides map[string]ratioSampler
+	next      sdktrace.Sampler
+}
+
+func (s endpointSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	return s.match(p).ShouldSample(p)
+}
+
+func (s endpointSampler) match(p sdktrace.SamplingParameters) sdktrace.Sampler {
+	if sampler, ok := s.overrides[spanEndpoint(p)]; ok {
+		return sampler
+	}
+	return s.next
+}
+
+func (s endpointSampler) Description() string {
+	return fmt.Sprintf("EndpointSampler{overrides:%d,%s}", len(s.overrides), s.next.Description())
+}
+
+func spanEndpoint(p sdktrace.SamplingParameters) string {
+	for _, attr := range p.Attributes {
+		if attr.Key == semconv.HTTPRequestMethodKey {
+			return attr.Value.AsString() + " " + spanRoute(p)
+		}
+	}
+	return ""
+}
+
+// spanRoute returns the route known when the span starts: http.route as set
+// by otelgin, then url.path, then the span name.
+func spanRoute(p sdktrace.SamplingParameters) string {
+	var path string
+	for _, attr := range p.Attributes {
+		switch attr.Key {
+		case semconv.HTTPRouteKey:
+			if route := attr.Value.AsString(); route != "" {
+				return route
+			}
+		case semconv.URLPathKey:
+			path = attr.Value.AsString()
+		}
+	}
+	if path != "" {
+		return path
+	}
+	return p.Name
+}
+
+// ratioSampler is TraceIDRatioBased remembering its ratio.
+type ratioSampler struct {
+	sdktrace.Sampler
+
+	ratio float64
+}
+
+func newRatioSampler(ratio float64) ratioSampler {
+	ratio = clampRate(ratio)
+	return ratioSampler{Sampler: sdktrace.TraceIDRatioBased(ratio), ratio: ratio}
+}
+
+// SamplingAttributeScheme selects how root spans advertise their head
+// sampling probability to collector tail samplers.
+type SamplingAttributeScheme string
+
+const (
+	// SamplingProbability sets sampling.probability to the ratio, e.g. 0.1.
+	SamplingProbability SamplingAttributeScheme = "probability"
+	// SamplingAdjustedCount sets sampling.adjusted_count to 1/ratio, the
+	// number of spans each exported span stands for.
+	SamplingAdjustedCount SamplingAttributeScheme = "adjusted_count"
+	// SamplingPriority sets sampling.priority=1, which the collector
+	// probabilistic sampler honours by keeping the span.
+	SamplingPriority SamplingAttributeScheme = "priority"
+)
+
+// probabilityAttributeSampler annotates sampled root spans with the
+// probability they were sampled at.
+type probabilityAttributeSampler struct {
+	next   sdktrace.Sampler
+	scheme SamplingAttributeScheme
+}
+
+func (s probabilityAttributeSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	result := s.next.ShouldSample(p)
+	if result.Decision != sdktrace.RecordAndSample {
+		return result
+	}
+	ratio := samplingRatio(s.next, p)
+	switch s.scheme {
+	case SamplingProbability:
+		result.Attributes = append(result.Attributes, attribute.Float64("sampling.probability", ratio))
+	case SamplingAdjustedCount:
+		if ratio > 0 {
+			result.Attributes = append(result.Attributes, attribute.Float64("sampling.adjusted_count", 1/ratio))
+		}
+	case SamplingPriority:
+		result.Attributes = append(result.Attributes, attribute.Int("sampling.priority", 1))
+	}
+	return result
+}
+
+func (s probabilityAttributeSampler) Description() string {
+	return s.next.Description()
+}
+
+// samplingRatio returns the ratio sampler applies to p.
+func samplingRatio(sampler sdktrace.Sampler, p sdktrace.SamplingParameters) float64 {
+	switch s := sampler.(type) {
+	case ratioSampler:
+		return s.ratio
+	case *DynamicSampler:
+		return s.Ratio()
+	case probabilityAttributeSampler:
+		return samplingRatio(s.next, p)
+	case routeSampler:
+		return samplingRatio(s.match(p), p)
+	case endpointSampler:
+		return samplingRatio(s.match(p), p)
+	case spanKindSampler:
+		return samplingRatio(s.match(p), p)
+	case warmupSampler:
+		if s.dynamic.warming.Load() {
+			return 1
+		}
+		return samplingRatio(s.next, p)
+	}
+	return 1
+}
+
+// samplingReasonSampler records on sampled root spans why they were sampled
+// as sampling.reason: "ratio", "dynamic_ratio", "internal_ratio", "warmup",
+// "route:<pattern>", "endpoint:<METHOD route>" or "remote_parent".
+type samplingReasonSampler struct {
+	next sdktrace.Sampler
+	root sdktrace.Sampler
+}
+
+func (s samplingReasonSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	result := s.next.ShouldSample(p)
+	if result.Decision != sdktrace.RecordAndSample {
+		return result
+	}
+	reason := ""
+	if parent := trace.SpanContextFromContext(p.ParentContext); !parent.IsValid() {
+		reason = samplingReason(s.root, p)
+	} else if parent.IsRemote() {
+		reason = "remote_parent"
+	}
+	if reason != "" {
+		result.Attributes = append(result.Attributes, attribute.String("sampling.reason", reason))
+	}
+	return result
+}
+
+func (s samplingReasonSampler) Description() string {
+	return s.next.Description()
+}
+
+func samplingReason(sampler sdktrace.Sampler, p sdktrace.SamplingParameters) string {
+	switch s := sampler.(type) {
+	case ratioSampler:
+		return "ratio"
+	case *DynamicSampler:
+		return "dynamic_ratio"
+	case probabilityAttributeSampler:
+		return samplingReason(s.next, p)
+	case routeSampler:
+		if rule, ok := s.matchRule(p); ok {
+			return "route:" + rule.pattern.String()
+		}
+		return samplingReason(s.next, p)
+	case spanKindSampler:
+		if p.Kind != trace.SpanKindServer {
+			return "internal_ratio"
+		}
+		return samplingReason(s.server, p)
+	case endpointSampler:
+		if endpoint := spanEndpoint(p); endpoint != "" {
+			if _, ok := s.overrides[endpoint]; ok {
+				return "endpoint:" + endpoint
+			}
+		}
+		return samplingReason(s.next, p)
+	case warmupSampler:
+		if s.dynamic.warming.Load() {
+			return "warmup"
+		}
+		return samplingReason(s.next, p)
+	}
+	return sampler.Description()
+}
+
+// cancelledSampler drops spans whose parent context is already cancelled.
+type cancelledSampler struct {
+	next sdktrace.Sampler
+}
+
+func (s cancelledSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	if p.ParentContext != nil && p.ParentContext.Err() != nil {
+		return sdktrace.SamplingResult{Decision: sdktrace.Drop}
+	}
+	return s.next.ShouldSample(p)
+}
+
+func (s cancelledSampler) Description() string {
+	return "CancelledSampler{" + s.next.Description() + "}"
+}
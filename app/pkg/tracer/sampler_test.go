@@ -0,0 +1,218 @@
+package tracer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Praisindo/pkg-library/app/pkg/tracer/tracertest"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newSamplerTestProvider(t *testing.T, config Config) (trace.Tracer, *tracetest.SpanRecorder) {
+	t.Helper()
+	sampler, _, err := buildSampler(config)
+	if err != nil {
+		t.Fatalf("buildSampler: %v", err)
+	}
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSampler(sampler), sdktrace.WithSpanProcessor(recorder))
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+	return tp.Tracer("test"), recorder
+}
+
+func remoteParent(sampled bool) context.Context {
+	var flags trace.TraceFlags
+	if sampled {
+		flags = trace.FlagsSampled
+	}
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{1},
+		TraceFlags: flags,
+		Remote:     true,
+	})
+	return trace.ContextWithRemoteSpanContext(context.Background(), sc)
+}
+
+func endedNames(recorder *tracetest.SpanRecorder) map[string]bool {
+	names := map[string]bool{}
+	for _, span := range recorder.Ended() {
+		names[span.Name()] = true
+	}
+	return names
+}
+
+func TestStrictHeadSampler(t *testing.T) {
+	route := trace.WithAttributes(semconv.HTTPRequestMethodKey.String("GET"), semconv.HTTPRoute("/orders/:id"))
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name   string
+		config Config
+		run    func(tracer trace.Tracer)
+		want   map[string]bool
+	}{
+		{
+			name:   "sampled remote parent wins over zero rate",
+			config: Config{TracerSamplingRate: "0"},
+			run: func(tracer trace.Tracer) {
+				_, span := tracer.Start(remoteParent(true), "child")
+				span.End()
+			},
+			want: map[string]bool{"child": true},
+		},
+		{
+			name:   "unsampled remote parent wins over full rate",
+			config: Config{TracerSamplingRate: "1"},
+			run: func(tracer trace.Tracer) {
+				_, span := tracer.Start(remoteParent(false), "child")
+				span.End()
+			},
+			want: map[string]bool{},
+		},
+		{
+			name:   "local child under unsampled root",
+			config: Config{TracerSamplingRate: "0"},
+			run: func(tracer trace.Tracer) {
+				ctx, root := tracer.Start(context.Background(), "root")
+				_, child := tracer.Start(ctx, "child")
+				child.End()
+				root.End()
+			},
+			want: map[string]bool{},
+		},
+		{
+			name: "route rule applies to roots only",
+			config: Config{
+				TracerSamplingRate: "0",
+				RouteSamplingRules: []RouteSamplingRule{{Pattern: "^/orders", Rate: 1}},
+			},
+			run: func(tracer trace.Tracer) {
+				_, root := tracer.Start(context.Background(), "root", route)
+				root.End()
+				_, child := tracer.Start(remoteParent(false), "child", route)
+				child.End()
+			},
+			want: map[string]bool{"root": true},
+		},
+		{
+			name: "endpoint override applies to roots only",
+			config: Config{
+				TracerSamplingRate:        "1",
+				EndpointSamplingOverrides: map[string]float64{"GET /orders/:id": 0},
+			},
+			run: func(tracer trace.Tracer) {
+				_, root := tracer.Start(context.Background(), "root", route)
+				root.End()
+				_, child := tracer.Start(remoteParent(true), "child", route)
+				child.End()
+			},
+			want: map[string]bool{"child": true},
+		},
+		{
+			name: "cancelled drop does not split a sampled trace",
+			config: Config{
+				TracerSamplingRate: "1",
+				CancelledSpans:     CancelledSpanDrop,
+			},
+			run: func(tracer trace.Tracer) {
+				ctx, root := tracer.Start(context.Background(), "root")
+				ctx, cancel := context.WithCancel(ctx)
+				cancel()
+				_, child := tracer.Start(ctx, "child")
+				child.End()
+				root.End()
+				_, orphan := tracer.Start(cancelled, "cancelled-root")
+				orphan.End()
+			},
+			want: map[string]bool{"root": true, "child": true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.StrictHeadSampling = true
+			tracer, recorder := newSamplerTestProvider(t, tt.config)
+			tt.run(tracer)
+			tracertest.AssertNoLeakedSpans(t, recorder)
+			got := endedNames(recorder)
+			if len(got) != len(tt.want) {
+				t.Fatalf("sampled spans = %v, want %v", got, tt.want)
+			}
+			for name := range tt.want {
+				if !got[name] {
+					t.Errorf("span %q not sampled, got %v", name, got)
+				}
+			}
+		})
+	}
+}
+
+func TestAdaptiveRatioCountsDynamicDecisionsOnly(t *testing.T) {
+	config := Config{
+		TracerSamplingRate:   "1",
+		TargetSpansPerSecond: 100,
+		InternalSamplingRate: "1",
+		RouteSamplingRules:   []RouteSamplingRule{{Pattern: "^/health", Rate: 1}},
+	}
+	sampler, dynamic, err := buildSampler(config)
+	if err != nil {
+		t.Fatalf("buildSampler: %v", err)
+	}
+	adaptive := newAdaptiveRatio(dynamic, config.TargetSpansPerSecond, 1)
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSampler(sampler))
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+	tracer := tp.Tracer("test")
+
+	ctx, root := tracer.Start(context.Background(), "GET /orders", trace.WithSpanKind(trace.SpanKindServer))
+	_, child := tracer.Start(ctx, "query", trace.WithSpanKind(trace.SpanKindServer))
+	_, remote := tracer.Start(remoteParent(true), "consume", trace.WithSpanKind(trace.SpanKindServer))
+	_, health := tracer.Start(context.Background(), "GET /health", trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(semconv.HTTPRoute("/health")))
+	_, internal := tracer.Start(context.Background(), "cleanup", trace.WithSpanKind(trace.SpanKindInternal))
+	for _, span := range []trace.Span{child, remote, health, internal, root} {
+		if !span.SpanContext().IsSampled() {
+			t.Fatalf("span not sampled")
+		}
+		span.End()
+	}
+
+	if got := adaptive.spans.Load(); got != 1 {
+		t.Errorf("adaptive counted %d spans, want 1 (the ratio-decided root)", got)
+	}
+}
+
+func TestWarmupSamplesAllRoots(t *testing.T) {
+	config := Config{
+		TracerSamplingRate:        "0",
+		WarmupDuration:            time.Hour,
+		InternalSamplingRate:      "0",
+		RouteSamplingRules:        []RouteSamplingRule{{Pattern: "^/health", Rate: 0}},
+		EndpointSamplingOverrides: map[string]float64{"GET /orders/:id": 0},
+	}
+	tracer, recorder := newSamplerTestProvider(t, config)
+	spans := []struct {
+		name string
+		opts []trace.SpanStartOption
+	}{
+		{"plain", []trace.SpanStartOption{trace.WithSpanKind(trace.SpanKindServer)}},
+		{"route", []trace.SpanStartOption{trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(semconv.HTTPRoute("/health"))}},
+		{"endpoint", []trace.SpanStartOption{trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.HTTPRequestMethodKey.String("GET"), semconv.HTTPRoute("/orders/:id"))}},
+		{"internal", []trace.SpanStartOption{trace.WithSpanKind(trace.SpanKindInternal)}},
+	}
+	for _, s := range spans {
+		_, span := tracer.Start(context.Background(), s.name, s.opts...)
+		span.End()
+	}
+	got := endedNames(recorder)
+	for _, s := range spans {
+		if !got[s.name] {
+			t.Errorf("root %q not sampled during warmup", s.name)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package tracer
+
+import (
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type instrumentationScope struct {
+	name    string
+	version string
+}
+
+var packageScope atomic.Pointer[instrumentationScope]
+
+// packageTracer returns the tracer used for spans started by this package,
+// named after Config.InstrumentationScope when set.
+func packageTracer() trace.Tracer {
+	if s := packageScope.Load(); s != nil && s.name != "" {
+		return otel.Tracer(s.name, trace.WithInstrumentationVersion(s.version))
+	}
+	return otel.Tracer(instrumentationName)
+}
+
+// scopedTracerProvider hands out tracers under a fixed instrumentation scope,
+// whatever name the instrumentation asks for. It lets otelgin spans share the
+// scope of manual spans.
+type scopedTracerProvider struct {
+	trace.TracerProvider
+
+	name    string
+	version string
+}
+
+func (p scopedTracerProvider) Tracer(_ string, opts ...trace.TracerOption) trace.Tracer {
+	if p.version != "" {
+		opts = append(opts, trace.WithInstrumentationVersion(p.version))
+	}
+	return p.TracerProvider.Tracer(p.name, opts...)
+}
@@ -0,0 +1,61 @@
+package tracer
+
+import (
+	"context"
+	"os"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const sourceInstanceKey = attribute.Key("source.instance")
+
+// sourceInstance names the replica writing spans: POD_NAME when set, the
+// hostname otherwise.
+func sourceInstance() string {
+	if pod := os.Getenv("POD_NAME"); pod != "" {
+		return pod
+	}
+	host, _ := os.Hostname()
+	return host
+}
+
+// sourceInstanceExporter stamps source.instance on exported spans that do
+// not carry it yet, so wrapping it twice changes nothing.
+type sourceInstanceExporter struct {
+	sdktrace.SpanExporter
+
+	instance string
+}
+
+func (e sourceInstanceExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	stamped := make([]sdktrace.ReadOnlySpan, len(spans))
+	for i, s := range spans {
+		if hasAttribute(s.Attributes(), sourceInstanceKey) {
+			stamped[i] = s
+		} else {
+			stamped[i] = sourceInstanceSpan{ReadOnlySpan: s, instance: e.instance}
+		}
+	}
+	return e.SpanExporter.ExportSpans(ctx, stamped)
+}
+
+func hasAttribute(attrs []attribute.KeyValue, key attribute.Key) bool {
+	for _, attr := range attrs {
+		if attr.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+type sourceInstanceSpan struct {
+	sdktrace.ReadOnlySpan
+
+	instance string
+}
+
+func (s sourceInstanceSpan) Attributes() []attribute.KeyValue {
+	attrs := s.ReadOnlySpan.Attributes()
+	return append(attrs[:len(attrs):len(attrs)], sourceInstanceKey.String(s.instance))
+}
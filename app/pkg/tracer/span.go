@@ -0,0 +1,293 @@
+package tracer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+type operationNameKey struct{}
+
+// WithOperationName scopes ctx under an operation name. Spans started through
+// StartSpan with the returned context are named "<operation>/<name>", and
+// nested operations are joined with "/".
+func WithOperationName(ctx context.Context, name string) context.Context {
+	if parent := operationName(ctx); parent != "" {
+		name = parent + "/" + name
+	}
+	return context.WithValue(ctx, operationNameKey{}, name)
+}
+
+func operationName(ctx context.Context) string {
+	name, _ := ctx.Value(operationNameKey{}).(string)
+	return name
+}
+
+type suppressTracingKey struct{}
+
+// SuppressTracing returns a context under which StartSpan, and the helpers
+// built on it, return non-recording spans, e.g. around a chatty third-party
+// call. The trace context is still propagated. Spans started directly from a
+// tracer, including by instrumentation libraries, are not affected.
+func SuppressTracing(ctx context.Context) context.Context {
+	return context.WithValue(ctx, suppressTracingKey{}, true)
+}
+
+func tracingSuppressed(ctx context.Context) bool {
+	suppressed, _ := ctx.Value(suppressTracingKey{}).(bool)
+	return suppressed
+}
+
+// StartSpan starts a span from the global tracer provider, prefixing name
+// with the operation set by WithOperationName. It returns a non-recording
+// span under SuppressTracing.
+func StartSpan(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	if tracingSuppressed(ctx) {
+		return noop.NewTracerProvider().Tracer(instrumentationName).Start(ctx, name)
+	}
+	if op := operationName(ctx); op != "" {
+		name = op + "/" + name
+	}
+	return packageTracer().Start(ctx, name, opts...)
+}
+
+// StartSpanWithKind is StartSpan with the span kind set to kind.
+func StartSpanWithKind(ctx context.Context, kind trace.SpanKind, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return StartSpan(ctx, name, append(opts, trace.WithSpanKind(kind))...)
+}
+
+// StartServerSpan starts a span for handling an inbound request.
+func StartServerSpan(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return StartSpanWithKind(ctx, trace.SpanKindServer, name, opts...)
+}
+
+// StartClientSpan starts a span for an outbound request.
+func StartClientSpan(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return StartSpanWithKind(ctx, trace.SpanKindClient, name, opts...)
+}
+
+// StartProducerSpan starts a span for sending a message.
+func StartProducerSpan(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return StartSpanWithKind(ctx, trace.SpanKindProducer, name, opts...)
+}
+
+// StartConsumerSpan starts a span for processing a received message.
+func StartConsumerSpan(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return StartSpanWithKind(ctx, trace.SpanKindConsumer, name, opts...)
+}
+
+// EndWithStatus records code as http.response.status_code, sets the status
+// the registered ErrorStatusFunc picks for 4xx and 5xx codes (an error for
+// 5xx by default) and ends the span. Nil and non-recording spans are ignored.
+func EndWithStatus(span trace.Span, code int) {
+	if span == nil || !span.IsRecording() {
+		return
+	}
+	span.SetAttributes(semconv.HTTPResponseStatusCode(code))
+	if code >= http.StatusBadRequest {
+		if status, description := errorStatus(httpStatusError(code)); status != codes.Unset {
+			span.SetStatus(status, description)
+		}
+	}
+	span.End()
+}
+
+// TxSpan runs fn inside a span covering a whole database transaction. The
+// span carries db.operation=transaction and db.transaction.outcome, which is
+// "commit" when fn returns nil and "rollback" otherwise; the error is also
+// recorded on the span. The span is ended even if fn panics.
+func TxSpan(ctx context.Context, name string, fn func(ctx context.Context) error) (err error) {
+	ctx, span := StartSpan(ctx, name, trace.WithAttributes(attribute.String("db.operation", "transaction")))
+	defer func() {
+		if r := recover(); r != nil {
+			span.SetAttributes(attribute.String("db.transaction.outcome", "rollback"))
+			span.SetStatus(codes.Error, fmt.Sprint(r))
+			span.End()
+			panic(r)
+		}
+		if err != nil {
+			span.SetAttributes(attribute.String("db.transaction.outcome", "rollback"))
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		} else {
+			span.SetAttributes(attribute.String("db.transaction.outcome", "commit"))
+		}
+		span.End()
+	}()
+	return fn(ctx)
+}
+
+// WithSpanTimeout runs fn inside a span with a context that times out after
+// timeout (no timeout when it is not positive). The span records timeout_ms
+// and deadline_exceeded, which is true when the deadline expired before fn
+// returned; it then gets an error status even if fn returned nil. Errors
+// returned by fn are recorded with RecordError and returned.
+func WithSpanTimeout(ctx context.Context, name string, timeout time.Duration, fn func(ctx context.Context) error) error {
+	ctx, span := StartSpan(ctx, name)
+	defer span.End()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+		span.SetAttributes(attribute.Int64("timeout_ms", timeout.Milliseconds()))
+	}
+
+	err := fn(ctx)
+	exceeded := errors.Is(ctx.Err(), context.DeadlineExceeded)
+	span.SetAttributes(attribute.Bool("deadline_exceeded", exceeded))
+	if err != nil {
+		RecordError(ctx, err)
+	}
+	if exceeded {
+		span.SetStatus(codes.Error, context.DeadlineExceeded.Error())
+	}
+	return err
+}
+
+// RecordTimings adds a single "timings" event to the span in ctx with one
+// timing.<phase>_ms attribute per phase, e.g. {"db": 12ms, "cache": 1ms}.
+func RecordTimings(ctx context.Context, timings map[string]time.Duration) {
+	span := trace.SpanFromContext(ctx)
+	if len(timings) == 0 || !span.IsRecording() {
+		return
+	}
+	phases := make([]string, 0, len(timings))
+	for phase := range timings {
+		phases = append(phases, phase)
+	}
+	sort.Strings(phases)
+
+	attrs := make([]attribute.KeyValue, 0, len(timings))
+	for _, phase := range phases {
+		ms := float64(timings[phase]) / float64(time.Millisecond)
+		attrs = append(attrs, attribute.Float64("timing."+phase+"_ms", ms))
+	}
+	span.AddEvent("timings", trace.WithAttributes(attrs...))
+}
+
+// ForEachSpan calls fn for every item inside its own child span named
+// "name[i]" with a batch.item.index attribute. All items are processed; item
+// errors are recorded on their span and returned joined.
+func ForEachSpan[T any](ctx context.Context, name string, items []T, fn func(ctx context.Context, item T) error) error {
+	var errs []error
+	for i, item := range items {
+		itemCtx, span := StartSpan(ctx, fmt.Sprintf("%s[%d]", name, i),
+			trace.WithAttributes(attribute.Int("batch.item.index", i)))
+		if err := fn(itemCtx, item); err != nil {
+			RecordError(itemCtx, err)
+			errs = append(errs, fmt.Errorf("%s[%d]: %w", name, i, err))
+		}
+		span.End()
+	}
+	return errors.Join(errs...)
+}
+
+// IsSampled reports whether the span context in ctx has the sampled flag
+// set, i.e. whether the trace is exported. Unlike span.IsRecording it also
+// reflects the decision of a remote parent.
+func IsSampled(ctx context.Context) bool {
+	return trace.SpanContextFromContext(ctx).IsSampled()
+}
+
+// RecordQueueWait records the time between acceptedAt, when the request was
+// accepted into a queue, and now as queue.wait_ms on the span in ctx, so
+// queueing shows apart from processing time.
+func RecordQueueWait(ctx context.Context, acceptedAt time.Time) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+	wait := float64(time.Since(acceptedAt)) / float64(time.Millisecond)
+	span.SetAttributes(attribute.Float64("queue.wait_ms", wait))
+}
+
+type spanDefaultsKey struct{}
+
+// WithSpanDefaults returns a context under which every span started, by any
+// tracer of the provider, gets attrs, e.g. the tenant or shard of a request.
+// Nested calls add to the defaults of the parent context, later values
+// winning. Attributes set on the span itself take precedence.
+func WithSpanDefaults(ctx context.Context, attrs ...attribute.KeyValue) context.Context {
+	parent := spanDefaults(ctx)
+	defaults := make([]attribute.KeyValue, 0, len(parent)+len(attrs))
+	defaults = append(append(defaults, parent...), attrs...)
+	return context.WithValue(ctx, spanDefaultsKey{}, defaults)
+}
+
+func spanDefaults(ctx context.Context) []attribute.KeyValue {
+	attrs, _ := ctx.Value(spanDefaultsKey{}).([]attribute.KeyValue)
+	return attrs
+}
+
+// spanDefaultsProcessor applies the WithSpanDefaults attributes of the
+// parent context to starting spans.
+type spanDefaultsProcessor struct{}
+
+func (spanDefaultsProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	defaults := spanDefaults(parent)
+	if len(defaults) == 0 {
+		return
+	}
+	// Start attributes are already set; keep them over the defaults.
+	own := attribute.NewSet(s.Attributes()...)
+	for _, attr := range defaults {
+		if !own.HasValue(attr.Key) {
+			s.SetAttributes(attr)
+		}
+	}
+}
+
+func (spanDefaultsProcessor) OnEnd(sdktrace.ReadOnlySpan)      {}
+func (spanDefaultsProcessor) Shutdown(context.Context) error   { return nil }
+func (spanDefaultsProcessor) ForceFlush(context.Context) error { return nil }
+
+// Scope groups the spans of a long-running, multi-step operation: every span
+// started through it, and every span started under the contexts it returns,
+// carries the scope attributes.
+type Scope struct {
+	ctx   context.Context
+	attrs []attribute.KeyValue
+}
+
+// NewScope returns a Scope whose spans are children of the span in ctx and
+// carry attrs.
+func NewScope(ctx context.Context, attrs ...attribute.KeyValue) *Scope {
+	return &Scope{ctx: WithSpanDefaults(ctx, attrs...), attrs: attrs}
+}
+
+// StartSpan starts a span like the package StartSpan, under the scope
+// context and with the scope attributes.
+func (s *Scope) StartSpan(name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	// Scope attributes go first so the caller's options override them.
+	return StartSpan(s.ctx, name, append([]trace.SpanStartOption{trace.WithAttributes(s.attrs...)}, opts...)...)
+}
+
+// Context returns the scope context, for calls that start spans themselves.
+func (s *Scope) Context() context.Context {
+	return s.ctx
+}
+
+// RecordFlag adds a feature_flag.evaluation event to the span in ctx with
+// feature_flag.key and the evaluated variant, as feature_flag.result.variant
+// (formerly feature_flag.variant) per the semantic conventions. It is a
+// no-op without a recording span.
+func RecordFlag(ctx context.Context, flagKey string, variant string) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+	span.AddEvent("feature_flag.evaluation", trace.WithAttributes(
+		semconv.FeatureFlagKey(flagKey),
+		semconv.FeatureFlagResultVariant(variant),
+	))
+}
@@ -0,0 +1,217 @@
+package tracer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// exportStats counts spans flowing through the export pipeline of one
+// exporter.
+type exportStats struct {
+	tool   TracingTool
+	canary bool
+	// maxQueue is the batch processor's queue size. Spans are only handed
+	// to it while fewer than maxQueue are pending, so it never drops spans
+	// itself and every drop is counted here.
+	maxQueue int64
+	// probe sends a canary span straight to the exporter.
+	probe func(context.Context) error
+
+	pending  atomic.Int64
+	queued   atomic.Int64
+	exported atomic.Int64
+	failed   atomic.Int64
+	dropped  atomic.Int64
+	errors   atomic.Int64
+
+	mu          sync.Mutex
+	lastExport  time.Time
+	lastError   string
+	lastErrorAt time.Time
+}
+
+// bspMaxQueueSize is the batch processor queue size, from
+// OTEL_BSP_MAX_QUEUE_SIZE like the SDK.
+func bspMaxQueueSize() int {
+	if n, err := strconv.Atoi(os.Getenv("OTEL_BSP_MAX_QUEUE_SIZE")); err == nil && n > 0 {
+		return n
+	}
+	return sdktrace.DefaultMaxQueueSize
+}
+
+// admit reserves a queue slot for a span, counting it as dropped when the
+// queue is full.
+func (s *exportStats) admit() bool {
+	for {
+		n := s.pending.Load()
+		if n >= s.maxQueue {
+			s.dropped.Add(1)
+			return false
+		}
+		if s.pending.CompareAndSwap(n, n+1) {
+			s.queued.Add(1)
+			return true
+		}
+	}
+}
+
+func (s *exportStats) recordExport(n int, err error) {
+	s.pending.Add(-int64(n))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.failed.Add(int64(n))
+		s.errors.Add(1)
+		s.lastError, s.lastErrorAt = err.Error(), time.Now()
+		return
+	}
+	s.exported.Add(int64(n))
+	s.lastExport = time.Now()
+}
+
+// statsExporter records export outcomes in stats.
+type statsExporter struct {
+	sdktrace.SpanExporter
+
+	stats *exportStats
+}
+
+func (e statsExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	err := e.SpanExporter.ExportSpans(ctx, spans)
+	e.stats.recordExport(len(spans), err)
+	return err
+}
+
+// statsProcessor counts spans handed to the batch processor and drops them
+// itself once its queue is full.
+type statsProcessor struct {
+	sdktrace.SpanProcessor
+
+	stats *exportStats
+}
+
+func (p statsProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	// The batch processor ignores unsampled spans.
+	if !s.SpanContext().IsSampled() || !p.stats.admit() {
+		return
+	}
+	p.SpanProcessor.OnEnd(s)
+}
+
+var tracerStatus struct {
+	mu      sync.RWMutex
+	tool    string
+	sampler sdktrace.Sampler
+	dynamic *DynamicSampler
+	stats   []*exportStats
+}
+
+func setTracerStatus(tool string, sampler sdktrace.Sampler, dynamic *DynamicSampler, stats []*exportStats) {
+	tracerStatus.mu.Lock()
+	defer tracerStatus.mu.Unlock()
+	tracerStatus.tool = tool
+	tracerStatus.sampler = sampler
+	tracerStatus.dynamic = dynamic
+	tracerStatus.stats = stats
+}
+
+type statusResponse struct {
+	Initialized   bool             `json:"initialized"`
+	TracingTool   string           `json:"tracing_tool,omitempty"`
+	Sampler       string           `json:"sampler,omitempty"`
+	SamplingRatio *float64         `json:"sampling_ratio,omitempty"`
+	Exporters     []exporterStatus `json:"exporters,omitempty"`
+}
+
+type exporterStatus struct {
+	Tool          string    `json:"tool"`
+	Canary        bool      `json:"canary,omitempty"`
+	Healthy       bool      `json:"healthy"`
+	Reachable     *bool     `json:"reachable,omitempty"`
+	ProbeError    string    `json:"probe_error,omitempty"`
+	SpansQueued   int64     `json:"spans_queued"`
+	SpansExported int64     `json:"spans_exported"`
+	SpansFailed   int64     `json:"spans_failed"`
+	SpansDropped  int64     `json:"spans_dropped"`
+	SpansBuffered int64     `json:"spans_buffered"`
+	ExportErrors  int64     `json:"export_errors"`
+	LastExport    time.Time `json:"last_export"`
+	LastError     string    `json:"last_error,omitempty"`
+	LastErrorAt   time.Time `json:"last_error_at"`
+}
+
+// StatusHandler serves the live tracer state as JSON: the active sampler and
+// ratio and the counters of each exporter, including spans dropped because
+// its export queue was full. With ?probe=true it also sends a
+// tracing.status_probe span to every exporter and reports whether the
+// backend was reachable. Mount it on e.g. /debug/tracing/status. It answers
+// 503 until InitTracer has run.
+func StatusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		probe, _ := strconv.ParseBool(r.URL.Query().Get("probe"))
+		resp := currentStatus(r.Context(), probe)
+		w.Header().Set("Content-Type", "application/json")
+		if !resp.Initialized {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+}
+
+func currentStatus(ctx context.Context, probe bool) statusResponse {
+	tracerStatus.mu.RLock()
+	if tracerStatus.sampler == nil {
+		tracerStatus.mu.RUnlock()
+		return statusResponse{}
+	}
+	resp := statusResponse{
+		Initialized: true,
+		TracingTool: tracerStatus.tool,
+		Sampler:     tracerStatus.sampler.Description(),
+	}
+	if tracerStatus.dynamic != nil {
+		ratio := tracerStatus.dynamic.Ratio()
+		resp.SamplingRatio = &ratio
+	}
+	stats := tracerStatus.stats
+	tracerStatus.mu.RUnlock()
+
+	for _, s := range stats {
+		s.mu.Lock()
+		exp := exporterStatus{
+			Tool:          string(s.tool),
+			Canary:        s.canary,
+			SpansQueued:   s.queued.Load(),
+			SpansExported: s.exported.Load(),
+			SpansFailed:   s.failed.Load(),
+			SpansDropped:  s.dropped.Load(),
+			SpansBuffered: s.pending.Load(),
+			ExportErrors:  s.errors.Load(),
+			LastExport:    s.lastExport,
+			LastError:     s.lastError,
+			LastErrorAt:   s.lastErrorAt,
+		}
+		s.mu.Unlock()
+		exp.Healthy = exp.LastErrorAt.IsZero() || exp.LastExport.After(exp.LastErrorAt)
+		if probe && s.probe != nil {
+			probeCtx, cancel := context.WithTimeout(ctx, validateTimeout)
+			err := s.probe(probeCtx)
+			cancel()
+			reachable := err == nil || !isConnectivityError(err)
+			exp.Reachable = &reachable
+			if err != nil {
+				exp.ProbeError = err.Error()
+			}
+		}
+		resp.Exporters = append(resp.Exporters, exp)
+	}
+	return resp
+}
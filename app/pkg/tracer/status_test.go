@@ -0,0 +1,46 @@
+package tracer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestStatusCountsDropsPerExporter(t *testing.T) {
+	primary := &exportStats{tool: "OTLP_GRPC", maxQueue: 2}
+	canary := &exportStats{tool: "OTLP_HTTP", canary: true, maxQueue: 2,
+		probe: func(context.Context) error { return context.DeadlineExceeded }}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(statsProcessor{SpanProcessor: tracetest.NewSpanRecorder(), stats: primary}),
+		sdktrace.WithSpanProcessor(statsProcessor{SpanProcessor: tracetest.NewSpanRecorder(), stats: canary}),
+	)
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+	for i := 0; i < 3; i++ {
+		_, span := tp.Tracer("test").Start(context.Background(), "op")
+		span.End()
+	}
+	primary.recordExport(2, nil)
+	canary.recordExport(2, errors.New("unavailable"))
+
+	setTracerStatus("OTLP_GRPC", sdktrace.AlwaysSample(), nil, []*exportStats{primary, canary})
+	t.Cleanup(func() { setTracerStatus("", nil, nil, nil) })
+	resp := currentStatus(context.Background(), true)
+
+	if len(resp.Exporters) != 2 {
+		t.Fatalf("got %d exporters, want 2", len(resp.Exporters))
+	}
+	for _, exp := range resp.Exporters {
+		if exp.SpansQueued != 2 || exp.SpansDropped != 1 || exp.SpansBuffered != 0 {
+			t.Errorf("%s: queued %d dropped %d buffered %d, want 2 1 0", exp.Tool, exp.SpansQueued, exp.SpansDropped, exp.SpansBuffered)
+		}
+	}
+	if got := resp.Exporters[0]; !got.Healthy || got.Reachable != nil {
+		t.Errorf("primary = %+v, want healthy and not probed", got)
+	}
+	if got := resp.Exporters[1]; got.Healthy || got.Reachable == nil || *got.Reachable {
+		t.Errorf("canary = %+v, want unhealthy and unreachable", got)
+	}
+}
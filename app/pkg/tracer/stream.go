@@ -0,0 +1,117 @@
+package tracer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracedReader wraps r so the span records a stream.first_read event with
+// the time to first byte and, once r hits EOF or is closed, the bytes read
+// as stream.bytes_read before ending. A nil span starts a "stream.read" span
+// from ctx. Close closes r too when it is an io.Closer.
+func TracedReader(ctx context.Context, r io.Reader, span trace.Span) io.ReadCloser {
+	if span == nil {
+		_, span = StartSpan(ctx, "stream.read")
+	}
+	return &tracedReader{r: r, stream: newTracedStream(span, "stream.first_read", "stream.bytes_read")}
+}
+
+// TracedWriter wraps w like TracedReader, recording stream.first_write and
+// stream.bytes_written. The span ends when the writer is closed.
+func TracedWriter(ctx context.Context, w io.Writer, span trace.Span) io.WriteCloser {
+	if span == nil {
+		_, span = StartSpan(ctx, "stream.write")
+	}
+	return &tracedWriter{w: w, stream: newTracedStream(span, "stream.first_write", "stream.bytes_written")}
+}
+
+type tracedReader struct {
+	r      io.Reader
+	stream *tracedStream
+}
+
+func (t *tracedReader) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	t.stream.record(n)
+	if err != nil {
+		t.stream.end(err)
+	}
+	return n, err
+}
+
+func (t *tracedReader) Close() error {
+	var err error
+	if c, ok := t.r.(io.Closer); ok {
+		err = c.Close()
+	}
+	t.stream.end(err)
+	return err
+}
+
+type tracedWriter struct {
+	w      io.Writer
+	stream *tracedStream
+}
+
+func (t *tracedWriter) Write(p []byte) (int, error) {
+	n, err := t.w.Write(p)
+	t.stream.record(n)
+	if err != nil {
+		t.stream.end(err)
+	}
+	return n, err
+}
+
+func (t *tracedWriter) Close() error {
+	var err error
+	if c, ok := t.w.(io.Closer); ok {
+		err = c.Close()
+	}
+	t.stream.end(err)
+	return err
+}
+
+// tracedStream holds the span bookkeeping shared by reader and writer.
+type tracedStream struct {
+	span       trace.Span
+	firstEvent string
+	bytesKey   attribute.Key
+	start      time.Time
+	bytes      atomic.Int64
+	first      sync.Once
+	done       sync.Once
+}
+
+func newTracedStream(span trace.Span, firstEvent, bytesKey string) *tracedStream {
+	return &tracedStream{span: span, firstEvent: firstEvent, bytesKey: attribute.Key(bytesKey), start: time.Now()}
+}
+
+func (s *tracedStream) record(n int) {
+	if n == 0 {
+		return
+	}
+	s.first.Do(func() {
+		ms := float64(time.Since(s.start)) / float64(time.Millisecond)
+		s.span.AddEvent(s.firstEvent, trace.WithAttributes(attribute.Float64("stream.ttfb_ms", ms)))
+	})
+	s.bytes.Add(int64(n))
+}
+
+func (s *tracedStream) end(err error) {
+	s.done.Do(func() {
+		if err != nil && !errors.Is(err, io.EOF) {
+			s.span.RecordError(err)
+			s.span.SetStatus(codes.Error, err.Error())
+		}
+		s.span.SetAttributes(s.bytesKey.Int64(s.bytes.Load()))
+		s.span.End()
+	})
+}
@@ -0,0 +1,91 @@
+package tracer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// TracingTool names a backend for Config.TracingTool, which stays a plain
+// string so values read from the environment can be assigned directly.
+type TracingTool string
+
+const (
+	ToolGCP      TracingTool = "GCP"
+	ToolStdout   TracingTool = "STDOUT"
+	ToolFile     TracingTool = "FILE"
+	ToolKafka    TracingTool = "KAFKA"
+	ToolJaeger   TracingTool = "JAEGER"
+	ToolSigNoz   TracingTool = "SIGNOZ"
+	ToolOTLP     TracingTool = "OTLP"
+	ToolOTLPGRPC TracingTool = "OTLP_GRPC"
+	ToolOTLPHTTP TracingTool = "OTLP_HTTP"
+	// ToolCloudEvents posts spans as CloudEvents to Config.CloudEventsSink.
+	ToolCloudEvents TracingTool = "CLOUDEVENTS"
+	// ToolLocal prints one compact span tree per trace to stdout, for local
+	// development without a collector.
+	ToolLocal TracingTool = "LOCAL"
+)
+
+var knownTools = map[TracingTool]bool{
+	ToolGCP: true, ToolStdout: true, ToolFile: true, ToolKafka: true, ToolJaeger: true,
+	ToolSigNoz: true, ToolOTLP: true, ToolOTLPGRPC: true, ToolOTLPHTTP: true, ToolCloudEvents: true,
+	ToolLocal: true,
+}
+
+// ParseTracingTools parses a comma separated TracingTool value such as
+// "OTLP,STDOUT". Each tool must match one of the Tool constants exactly,
+// ignoring case and surrounding spaces. The known tools are returned even
+// when the error reports unknown ones.
+func ParseTracingTools(value string) ([]TracingTool, error) {
+	var parsed []TracingTool
+	var unknown []string
+	for _, name := range strings.Split(value, ",") {
+		tool := TracingTool(strings.ToUpper(strings.TrimSpace(name)))
+		switch {
+		case tool == "":
+		case knownTools[tool]:
+			parsed = append(parsed, tool)
+		default:
+			unknown = append(unknown, strings.TrimSpace(name))
+		}
+	}
+	if len(unknown) > 0 {
+		return parsed, fmt.Errorf("unknown tracing tool %s", strings.Join(unknown, ", "))
+	}
+	return parsed, nil
+}
+
+// hasTool reports whether config.TracingTool selects one of tools.
+func hasTool(config Config, tools ...TracingTool) bool {
+	parsed, _ := ParseTracingTools(config.TracingTool)
+	for _, p := range parsed {
+		for _, tool := range tools {
+			if p == tool {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseExporterSamplingRates normalises the keys of
+// Config.ExporterSamplingRates like ParseTracingTools and checks that each
+// names a known tool and has a rate in [0, 1].
+func parseExporterSamplingRates(rates map[string]float64) (map[TracingTool]float64, error) {
+	parsed := make(map[TracingTool]float64, len(rates))
+	var errs []error
+	for name, rate := range rates {
+		tool := TracingTool(strings.ToUpper(strings.TrimSpace(name)))
+		if !knownTools[tool] {
+			errs = append(errs, fmt.Errorf("unknown tracing tool %q in ExporterSamplingRates", name))
+			continue
+		}
+		if !(rate >= 0 && rate <= 1) {
+			errs = append(errs, fmt.Errorf("ExporterSamplingRates[%q] is %v, want a rate in [0, 1]", name, rate))
+			continue
+		}
+		parsed[tool] = rate
+	}
+	return parsed, errors.Join(errs...)
+}
@@ -0,0 +1,27 @@
+package tracer
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseExporterSamplingRates(t *testing.T) {
+	rates, err := parseExporterSamplingRates(map[string]float64{" otlp ": 0.1, "Stdout": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rates[ToolOTLP] != 0.1 || rates[ToolStdout] != 1 {
+		t.Errorf("rates = %v, want OTLP 0.1 and STDOUT 1", rates)
+	}
+
+	for _, bad := range []map[string]float64{
+		{"OTLPP": 0.5},
+		{"OTLP": 1.5},
+		{"OTLP": -0.1},
+		{"OTLP": math.NaN()},
+	} {
+		if _, err := parseExporterSamplingRates(bad); err == nil {
+			t.Errorf("parseExporterSamplingRates(%v) returned no error", bad)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package tracer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type recordingLogger struct {
+	lines []string
+}
+
+func (l *recordingLogger) Printf(format string, args ...any) {
+	l.lines = append(l.lines, format)
+}
+
+func TestResolveServiceName(t *testing.T) {
+	executable := filepath.Base(os.Args[0])
+	tests := []struct {
+		name        string
+		serviceName string
+		env         string
+		want        string
+		warned      bool
+	}{
+		{name: "argument wins over env", serviceName: "orders", env: "from-env", want: "orders"},
+		{name: "env when argument empty", serviceName: "", env: "from-env", want: "from-env"},
+		{name: "env when argument blank", serviceName: "  ", env: "from-env", want: "from-env"},
+		{name: "executable when both empty", serviceName: "", env: "", want: executable, warned: true},
+		{name: "executable when env blank", serviceName: "", env: " ", want: executable, warned: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("OTEL_SERVICE_NAME", tt.env)
+			logger := &recordingLogger{}
+			if got := resolveServiceName(tt.serviceName, logger); got != tt.want {
+				t.Errorf("resolveServiceName(%q) = %q, want %q", tt.serviceName, got, tt.want)
+			}
+			if warned := len(logger.lines) > 0; warned != tt.warned {
+				t.Errorf("logged warning = %t, want %t", warned, tt.warned)
+			}
+		})
+	}
+}
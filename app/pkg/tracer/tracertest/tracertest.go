@@ -0,0 +1,62 @@
+// Package tracertest provides helpers for asserting on traces in tests.
+package tracertest
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SameTrace reports whether a and b carry span contexts of the same trace.
+// It is false when either context has no valid span context.
+func SameTrace(a, b context.Context) bool {
+	sa, sb := trace.SpanContextFromContext(a), trace.SpanContextFromContext(b)
+	return sa.IsValid() && sb.IsValid() && sa.TraceID() == sb.TraceID()
+}
+
+// NewProvider returns a tracer provider sampling every span and a recorder
+// of the spans it starts and ends.
+func NewProvider() (*sdktrace.TracerProvider, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+		sdktrace.WithSpanProcessor(recorder),
+	)
+	return tp, recorder
+}
+
+// AssertNoLeakedSpans fails t, listing the span names, when spans started on
+// recorder were never ended. Call it at the end of a test, typically from
+// t.Cleanup, to catch a missing span.End.
+func AssertNoLeakedSpans(t testing.TB, recorder *tracetest.SpanRecorder) {
+	t.Helper()
+	var leaked []string
+	for _, span := range recorder.Started() {
+		if span.EndTime().IsZero() {
+			leaked = append(leaked, span.Name())
+		}
+	}
+	if len(leaked) > 0 {
+		t.Errorf("%d span(s) started but never ended: %s", len(leaked), strings.Join(leaked, ", "))
+	}
+}
+
+// AssertSpansHaveStatus fails t, listing the span names, when ended spans
+// never set a status.
+func AssertSpansHaveStatus(t testing.TB, recorder *tracetest.SpanRecorder) {
+	t.Helper()
+	var unset []string
+	for _, span := range recorder.Ended() {
+		if span.Status().Code == codes.Unset {
+			unset = append(unset, span.Name())
+		}
+	}
+	if len(unset) > 0 {
+		t.Errorf("%d span(s) ended without a status: %s", len(unset), strings.Join(unset, ", "))
+	}
+}
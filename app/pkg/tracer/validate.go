@@ -0,0 +1,103 @@
+package tracer
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const validateTimeout = 10 * time.Second
+
+// validateTracer checks the parts of config that are only used once the
+// pipeline runs, then exports a single tracing.validate span synchronously
+// to each exporter to prove the backends are reachable and accept our
+// credentials.
+func validateTracer(ctx context.Context, config Config, exporters []toolExporter, res *resource.Resource) error {
+	if len(exporters) == 0 {
+		return errors.New("tracing tool not recognised: " + config.TracingTool)
+	}
+	if _, err := compilePathRedactions(config.PathRedactions); err != nil {
+		return err
+	}
+
+	span := canarySpan("tracing.validate", res)
+
+	ctx, cancel := context.WithTimeout(ctx, validateTimeout)
+	defer cancel()
+	for _, e := range exporters {
+		if err := e.exporter.ExportSpans(ctx, []sdktrace.ReadOnlySpan{span}); err != nil {
+			return fmt.Errorf("tracing backend check failed for %s: %w", e.tool, err)
+		}
+	}
+	loggerOrDefault(config.Logger).Printf("Tracing configuration is valid for %s", config.TracingTool)
+	return nil
+}
+
+func canarySpan(name string, res *resource.Resource) sdktrace.ReadOnlySpan {
+	var traceID trace.TraceID
+	var spanID trace.SpanID
+	_, _ = rand.Read(traceID[:])
+	_, _ = rand.Read(spanID[:])
+	now := time.Now()
+	return tracetest.SpanStub{
+		Name: name,
+		SpanContext: trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    traceID,
+			SpanID:     spanID,
+			TraceFlags: trace.FlagsSampled,
+		}),
+		StartTime: now,
+		EndTime:   now,
+		Resource:  res,
+	}.Snapshot()
+}
+
+// selfCheck exports a tracing.self_check canary span to each exporter and
+// logs whether the backend could not be reached or rejected the span, e.g.
+// over a schema or credentials mismatch. Failures do not stop startup.
+// Spans partially rejected by an OTLP collector are reported through the SDK
+// error handler instead.
+func selfCheck(ctx context.Context, exporters []toolExporter, res *resource.Resource, logger Logger) {
+	span := canarySpan("tracing.self_check", res)
+
+	ctx, cancel := context.WithTimeout(ctx, validateTimeout)
+	defer cancel()
+	for _, e := range exporters {
+		err := e.exporter.ExportSpans(ctx, []sdktrace.ReadOnlySpan{span})
+		switch {
+		case err == nil:
+			logger.Printf("Tracing self-check passed for %s", e.tool)
+		case isConnectivityError(err):
+			logger.Printf("Tracing self-check failed for %s - backend unreachable (connectivity): %v", e.tool, err)
+		default:
+			logger.Printf("Tracing self-check failed for %s - backend rejected the canary span (check the schema URL %s and credentials): %v", e.tool, res.SchemaURL(), err)
+		}
+	}
+}
+
+func isConnectivityError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	if st, ok := status.FromError(err); ok {
+		switch st.Code() {
+		case codes.Unavailable, codes.DeadlineExceeded, codes.Canceled:
+			return true
+		}
+	}
+	return false
+}
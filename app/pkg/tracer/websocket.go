@@ -0,0 +1,51 @@
+package tracer
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// StartWebSocketSession starts the span covering a whole WebSocket session,
+// to be ended when the connection closes. Call it with the upgrade request
+// context: the session gets its own trace, linked to the upgrade request's
+// span, so the long-lived connection does not stretch the request trace.
+// Use the returned context for StartWebSocketMessage.
+func StartWebSocketSession(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	opts = append(opts,
+		trace.WithNewRoot(),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attribute.String("network.protocol.name", "websocket")),
+	)
+	if upgrade := trace.SpanContextFromContext(ctx); upgrade.IsValid() {
+		opts = append(opts, trace.WithLinks(trace.Link{
+			SpanContext: upgrade,
+			Attributes:  []attribute.KeyValue{attribute.String("link.type", "websocket.upgrade")},
+		}))
+	}
+	return StartSpan(ctx, name, opts...)
+}
+
+// WebSocketDirection tells whether a message was received or sent.
+type WebSocketDirection string
+
+const (
+	WebSocketReceive WebSocketDirection = "receive"
+	WebSocketSend    WebSocketDirection = "send"
+)
+
+// StartWebSocketMessage starts a child span of the session in ctx for a
+// single message, named "<name> <direction>" with websocket.message.direction
+// and, when size is not negative, websocket.message.size set.
+func StartWebSocketMessage(ctx context.Context, name string, direction WebSocketDirection, size int) (context.Context, trace.Span) {
+	kind := trace.SpanKindConsumer
+	if direction == WebSocketSend {
+		kind = trace.SpanKindProducer
+	}
+	attrs := []attribute.KeyValue{attribute.String("websocket.message.direction", string(direction))}
+	if size >= 0 {
+		attrs = append(attrs, attribute.Int("websocket.message.size", size))
+	}
+	return StartSpan(ctx, name+" "+string(direction), trace.WithSpanKind(kind), trace.WithAttributes(attrs...))
+}